@@ -0,0 +1,43 @@
+package structconfig
+
+import "strings"
+
+// DefaultAcronyms are common acronyms to set as Options.Acronyms, so
+// HTTPAPIKey becomes HTTP_API_KEY. Clone it before appending to it.
+var DefaultAcronyms = []string{
+	"API", "AWS", "CPU", "CSV", "DB", "DNS", "GCP", "GRPC", "HTML", "HTTP", "HTTPS",
+	"ID", "IO", "IP", "JSON", "JWT", "OS", "RPC", "SQL", "SSH", "SSL", "TCP", "TLS",
+	"TTL", "UDP", "UI", "URI", "URL", "UUID", "XML", "YAML",
+}
+
+// splitAcronyms splits a run of capitals such as HTTPAPI into the acronyms
+// it is made of, preferring longer acronyms. A run that cannot be split into
+// acronyms completely is returned whole.
+func splitAcronyms(run string, acronyms []string) []string {
+	if run == "" {
+		return nil
+	}
+
+	var best string
+
+	for _, a := range acronyms {
+		a = strings.ToUpper(a)
+		if len(a) <= len(best) || !strings.HasPrefix(run, a) {
+			continue
+		}
+
+		if a == run || splitAcronyms(run[len(a):], acronyms) != nil {
+			best = a
+		}
+	}
+
+	if best == "" {
+		return nil
+	}
+
+	if best == run {
+		return []string{run}
+	}
+
+	return append([]string{best}, splitAcronyms(run[len(best):], acronyms)...)
+}
@@ -0,0 +1,84 @@
+package structconfig
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// Addr is a network address in host:port form, such as "localhost:8080",
+// ":8080", or "[::1]:8080". The host may be empty to listen on all
+// interfaces, and port 0 asks the system for an ephemeral port. The port must
+// be numeric and within 0-65535.
+//
+// Addr implements pflag.Value, so Addr fields get a flag of type "host:port"
+// that rejects invalid addresses while flags are parsed.
+type Addr struct {
+	host string
+	port int
+	// set tells the parsed ":0" apart from the zero Addr.
+	set bool
+}
+
+// ParseAddr parses a host:port address.
+func ParseAddr(s string) (Addr, error) {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		return Addr{}, fmt.Errorf("invalid address %q: %w", s, err)
+	}
+
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 0 || n > 65535 {
+		return Addr{}, fmt.Errorf("invalid address %q: port must be a number between 0 and 65535", s)
+	}
+
+	return Addr{host: host, port: n, set: true}, nil
+}
+
+// Host returns the host part, without brackets for IPv6 addresses.
+func (a Addr) Host() string { return a.host }
+
+// Port returns the port number.
+func (a Addr) Port() int { return a.port }
+
+// IsZero reports whether the address is unset.
+func (a Addr) IsZero() bool { return !a.set }
+
+// String returns the address in host:port form, or "" when it is unset.
+func (a Addr) String() string {
+	if a.IsZero() {
+		return ""
+	}
+
+	return net.JoinHostPort(a.host, strconv.Itoa(a.port))
+}
+
+// Set implements pflag.Value. An empty string resets a to the zero Addr.
+func (a *Addr) Set(s string) error {
+	if s == "" {
+		*a = Addr{}
+		return nil
+	}
+
+	addr, err := ParseAddr(s)
+	if err != nil {
+		return err
+	}
+
+	*a = addr
+
+	return nil
+}
+
+// Type implements pflag.Value.
+func (a *Addr) Type() string { return "host:port" }
+
+// MarshalText implements encoding.TextMarshaler.
+func (a Addr) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *Addr) UnmarshalText(text []byte) error {
+	return a.Set(string(text))
+}
@@ -0,0 +1,230 @@
+package structconfig
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fieldComments returns the comment lines written above each key of the
+// default config: the field description and how to override the value.
+func (s *StructConfig) fieldComments() map[string][]string {
+	comments := make(map[string][]string, len(s.infos))
+
+	for _, info := range s.infos {
+		var lines []string
+
+		for _, line := range strings.Split(info.Description, "\n") {
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+
+		var hints []string
+
+		if info.Env != "" && info.Env != skipTagValue {
+			hints = append(hints, "env: "+info.Env)
+		}
+
+		if f := helpFlag(info); f != "" {
+			flag := "flag: --" + f
+			if info.ShortFlag != "" && info.ShortFlag != skipTagValue {
+				flag += " (-" + info.ShortFlag + ")"
+			}
+
+			hints = append(hints, flag)
+		}
+
+		if info.Required {
+			hints = append(hints, "required")
+		}
+
+		if len(hints) > 0 {
+			lines = append(lines, strings.Join(hints, ", "))
+		}
+
+		comments[info.Key] = lines
+	}
+
+	return comments
+}
+
+// dumpAnnotatedConfig encodes config like dumpConfig, with the field
+// comments above the keys they belong to in formats that have comments.
+func (s *StructConfig) dumpAnnotatedConfig(config map[string]any) (string, error) {
+	comments := s.fieldComments()
+
+	switch s.options.ConfigType {
+	case "toml":
+		out, err := s.dumpConfig(config)
+		if err != nil {
+			return "", err
+		}
+
+		return s.annotateTOML(out, comments), nil
+	case "yaml":
+		var doc yaml.Node
+		if err := doc.Encode(config); err != nil {
+			return "", err
+		}
+
+		s.annotateYAML(&doc, "", comments)
+
+		var buf strings.Builder
+		if err := yaml.NewEncoder(&buf).Encode(&doc); err != nil {
+			return "", err
+		}
+
+		return buf.String(), nil
+	default:
+		// JSON has no comments.
+		return s.dumpConfig(config)
+	}
+}
+
+// annotateTOML inserts comments above the key and table header lines of an
+// encoded TOML document, tracking the current table to build full keys.
+func (s *StructConfig) annotateTOML(text string, comments map[string][]string) string {
+	var (
+		b     strings.Builder
+		table []string
+	)
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+		var key string
+
+		switch {
+		case strings.HasPrefix(trimmed, "["):
+			table, _ = parseTOMLKey(strings.TrimLeft(trimmed, "["), ']')
+			key = s.tomlFieldKey(table)
+		default:
+			parts, ok := parseTOMLKey(trimmed, '=')
+			if !ok {
+				break
+			}
+
+			key = s.tomlFieldKey(append(slices.Clip(table), parts...))
+		}
+
+		if key != "" {
+			for _, comment := range comments[key] {
+				fmt.Fprintf(&b, "%s# %s\n", indent, comment)
+			}
+		}
+
+		b.WriteString(line)
+	}
+
+	return b.String()
+}
+
+// parseTOMLKey parses the dotted key at the start of text, made of bare,
+// "basic", and 'literal' segments, up to end: the = of a key/value line or
+// the ] of a table header. ok is false when text does not start with a key.
+func parseTOMLKey(text string, end byte) (parts []string, ok bool) {
+	for {
+		text = strings.TrimLeft(text, " \t")
+		if text == "" {
+			return nil, false
+		}
+
+		var part string
+
+		switch text[0] {
+		case '"':
+			i := 1
+			for i < len(text) && text[i] != '"' {
+				if text[i] == '\\' {
+					i++
+				}
+				i++
+			}
+
+			if i >= len(text) {
+				return nil, false
+			}
+
+			unquoted, err := strconv.Unquote(text[:i+1])
+			if err != nil {
+				return nil, false
+			}
+
+			part, text = unquoted, text[i+1:]
+		case '\'':
+			i := strings.IndexByte(text[1:], '\'')
+			if i < 0 {
+				return nil, false
+			}
+
+			part, text = text[1:i+1], text[i+2:]
+		default:
+			i := strings.IndexFunc(text, func(r rune) bool {
+				return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+			})
+			if i == 0 {
+				return nil, false
+			}
+
+			if i < 0 {
+				i = len(text)
+			}
+
+			part, text = text[:i], text[i:]
+		}
+
+		parts = append(parts, part)
+
+		text = strings.TrimLeft(text, " \t")
+		if text == "" || text[0] != '.' {
+			break
+		}
+
+		text = text[1:]
+	}
+
+	if text == "" || text[0] != end {
+		return nil, false
+	}
+
+	return parts, true
+}
+
+// tomlFieldKey joins TOML key segments with Options.KeyDelimiter. It returns
+// "" when a segment holds the delimiter, as the quoted key "a.b" does, since
+// such a key is a single map entry rather than the path of a field.
+func (s *StructConfig) tomlFieldKey(parts []string) string {
+	if len(parts) == 0 || slices.ContainsFunc(parts, func(p string) bool { return strings.Contains(p, s.options.KeyDelimiter) }) {
+		return ""
+	}
+
+	return strings.Join(parts, s.options.KeyDelimiter)
+}
+
+// annotateYAML sets the comments as head comments of the matching mapping
+// keys of an encoded YAML node tree.
+func (s *StructConfig) annotateYAML(node *yaml.Node, prefix string, comments map[string][]string) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			s.annotateYAML(child, prefix, comments)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode := node.Content[i]
+
+			key := s.keyPath(prefix, keyNode.Value)
+
+			if lines := comments[key]; len(lines) > 0 {
+				keyNode.HeadComment = "# " + strings.Join(lines, "\n# ")
+			}
+
+			s.annotateYAML(node.Content[i+1], key, comments)
+		}
+	}
+}
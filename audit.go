@@ -0,0 +1,73 @@
+package structconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AuditKind classifies a step recorded in the audit trail.
+type AuditKind string
+
+// Audit kinds, roughly in the order the steps happen.
+const (
+	AuditConfigFile AuditKind = "config-file"
+	AuditSecret     AuditKind = "secret"
+	AuditSource     AuditKind = "source"
+	AuditFeature    AuditKind = "feature"
+	AuditEnv        AuditKind = "env"
+	AuditFlag       AuditKind = "flag"
+	// AuditHook is a value transformed by a tag such as expand,
+	// duration_unit, normalize, or template.
+	AuditHook AuditKind = "hook"
+)
+
+// AuditEvent is one step of configuration resolution. Values are never
+// recorded, so the trail can be shared in support tickets.
+type AuditEvent struct {
+	Kind AuditKind
+	// Key is the config key, file path, env var, or flag the step refers to.
+	Key     string
+	Message string
+}
+
+// String formats the event as printed by the audit flag.
+func (e AuditEvent) String() string {
+	return fmt.Sprintf("%s: %s: %s", e.Kind, e.Key, e.Message)
+}
+
+// Audit returns the steps taken by the last Process, Reload, or Merge:
+// config file candidates tried, secrets and sources read, env vars and
+// flags applied, and values transformed by tags.
+func (s *StructConfig) Audit() []AuditEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]AuditEvent(nil), s.auditLog...)
+}
+
+// audit records a step for Audit.
+func (s *StructConfig) audit(kind AuditKind, key, format string, args ...any) {
+	s.auditLog = append(s.auditLog, AuditEvent{Kind: kind, Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+func (s *StructConfig) processAuditFlag() (string, error) {
+	if s.options.FlagNames.Audit == "" || s.options.FlagNames.Audit == skipBuiltInFlagValue {
+		return "", nil
+	}
+
+	printAudit, err := s.flags.GetBool(s.options.FlagNames.Audit)
+	if err != nil {
+		return "", err
+	}
+
+	if !printAudit {
+		return "", nil
+	}
+
+	var b strings.Builder
+	for _, e := range s.auditLog {
+		b.WriteString(e.String() + "\n")
+	}
+
+	return b.String(), ErrAuditCalled
+}
@@ -0,0 +1,50 @@
+package structconfig
+
+import (
+	"fmt"
+	"reflect"
+	"runtime/debug"
+)
+
+// buildInfoDefault returns the value of a buildinfo tag from the build info
+// embedded in the binary: "go", "path", "main.path", "main.version", and
+// "main.sum" name its fields, any other key a build setting such as
+// "vcs.revision", "vcs.time", or "GOOS". A setting the binary lacks, like
+// the vcs keys of a build outside a repository, yields "".
+func buildInfoDefault(field reflect.StructField, key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("%s tag on field %s requires a key such as vcs.revision", tagBuildInfo, field.Name)
+	}
+
+	for _, other := range []string{tagDefault, tagDefaultFunc} {
+		if _, ok := field.Tag.Lookup(other); ok {
+			return "", fmt.Errorf("field %s has both %s and %s tags", field.Name, other, tagBuildInfo)
+		}
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", nil
+	}
+
+	switch key {
+	case "go":
+		return info.GoVersion, nil
+	case "path":
+		return info.Path, nil
+	case "main.path":
+		return info.Main.Path, nil
+	case "main.version":
+		return info.Main.Version, nil
+	case "main.sum":
+		return info.Main.Sum, nil
+	}
+
+	for _, setting := range info.Settings {
+		if setting.Key == key {
+			return setting.Value, nil
+		}
+	}
+
+	return "", nil
+}
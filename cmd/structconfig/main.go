@@ -0,0 +1,193 @@
+// Command structconfig renders deployment artifacts from a structconfig spec:
+// an example config file, a .env.example, a systemd EnvironmentFile template,
+// a docker-compose environment block, a Markdown reference of the settings,
+// or a JSON Schema of the config file.
+//
+// It is meant to be run from go:generate next to the spec:
+//
+//	//go:generate go run github.com/justakit/structconfig/cmd/structconfig --type Config --prefix myapp --format env -o .env.example
+//
+// With --check, the output is compared with the existing file instead of
+// written, and the command exits with status 1 when they differ, so CI can
+// detect artifacts that have drifted from the code.
+//
+// The command writes a small program importing the spec's package to a
+// temporary directory and runs it with "go run" in the current module.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/pflag"
+)
+
+// generators maps --format values to the structconfig functions rendering them.
+var generators = map[string]string{
+	"config":  "ExampleConfig",
+	"env":     "EnvExample",
+	"systemd": "SystemdEnvironmentFile",
+	"compose": "ComposeEnvironment",
+	"doc":     "MarkdownDoc",
+	"schema":  "JSONSchema",
+}
+
+var errDrift = errors.New("generated output differs")
+
+type params struct {
+	Pkg        string
+	Type       string
+	Prefix     string
+	Format     string
+	ConfigType string
+	Output     string
+	Check      bool
+}
+
+func main() {
+	var p params
+
+	flags := pflag.NewFlagSet("structconfig", pflag.ExitOnError)
+	flags.StringVar(&p.Pkg, "pkg", ".", "package containing the spec, as an import path or directory")
+	flags.StringVar(&p.Type, "type", "", "name of the spec struct type (required)")
+	flags.StringVar(&p.Prefix, "prefix", "", "env var prefix passed to Process")
+	flags.StringVar(&p.Format, "format", "config", "output format: config, env, systemd, compose, doc, or schema")
+	flags.StringVar(&p.ConfigType, "config-type", "toml", "config file type for --format config: toml, yaml, or json")
+	flags.StringVarP(&p.Output, "output", "o", "", "output file (default stdout)")
+	flags.BoolVar(&p.Check, "check", false, "compare with the output file instead of writing it")
+	_ = flags.Parse(os.Args[1:])
+
+	if err := run(p); err != nil {
+		fmt.Fprintln(os.Stderr, "structconfig:", err)
+		os.Exit(1)
+	}
+}
+
+func run(p params) error {
+	if p.Type == "" {
+		return errors.New("--type is required")
+	}
+
+	if p.Check && p.Output == "" {
+		return errors.New("--check requires --output")
+	}
+
+	importPath, err := resolveImportPath(p.Pkg)
+	if err != nil {
+		return err
+	}
+
+	src, err := generatorSource(importPath, p)
+	if err != nil {
+		return err
+	}
+
+	out, err := runGenerator(src)
+	if err != nil {
+		return err
+	}
+
+	if p.Output == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+
+	if p.Check {
+		existing, err := os.ReadFile(p.Output)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(existing, out) {
+			return fmt.Errorf("%w: %s is out of date, regenerate it", errDrift, p.Output)
+		}
+
+		return nil
+	}
+
+	return os.WriteFile(p.Output, out, 0o644)
+}
+
+func resolveImportPath(pkg string) (string, error) {
+	cmd := exec.Command("go", "list", "-f", "{{.ImportPath}}", pkg)
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("resolve package %q: %w", pkg, err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+var generatorTemplate = template.Must(template.New("main").Parse(`// Code generated by structconfig. DO NOT EDIT.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/justakit/structconfig"
+	spec {{printf "%q" .ImportPath}}
+)
+
+func main() {
+	out, err := structconfig.{{.Func}}({{printf "%q" .Prefix}}, &spec.{{.Type}}{}, &structconfig.Options{
+		ConfigType: {{printf "%q" .ConfigType}},
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Print(out)
+}
+`))
+
+// generatorSource returns the program that renders p.Format for the spec.
+func generatorSource(importPath string, p params) (string, error) {
+	fn, ok := generators[p.Format]
+	if !ok {
+		return "", fmt.Errorf("unknown format %q: want config, env, systemd, compose, doc, or schema", p.Format)
+	}
+
+	var buf strings.Builder
+
+	err := generatorTemplate.Execute(&buf, struct {
+		ImportPath, Func, Prefix, Type, ConfigType string
+	}{importPath, fn, p.Prefix, p.Type, p.ConfigType})
+
+	return buf.String(), err
+}
+
+// runGenerator runs src with "go run" in the current module and returns its
+// standard output.
+func runGenerator(src string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "structconfig-gen-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	mainPath := filepath.Join(dir, "main.go")
+	if err = os.WriteFile(mainPath, []byte(src), 0o600); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("go", "run", mainPath)
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("run generator: %w", err)
+	}
+
+	return out, nil
+}
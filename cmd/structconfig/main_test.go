@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGeneratorSource(t *testing.T) {
+	src, err := generatorSource("example.com/app/config", params{Type: "Config", Prefix: "myapp", Format: "compose", ConfigType: "toml"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`spec "example.com/app/config"`,
+		`structconfig.ComposeEnvironment("myapp", &spec.Config{}`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("expected source to contain %q, got:\n%s", want, src)
+		}
+	}
+
+	for format, fn := range map[string]string{"doc": "MarkdownDoc", "schema": "JSONSchema"} {
+		src, err = generatorSource("example.com/app/config", params{Type: "Config", Format: format})
+		if err != nil || !strings.Contains(src, "structconfig."+fn+"(") {
+			t.Errorf("expected --format %s to call %s, got %v:\n%s", format, fn, err, src)
+		}
+	}
+
+	if _, err = generatorSource("example.com/app/config", params{Type: "Config", Format: "helm"}); err == nil {
+		t.Error("expected error for unknown format, got nil")
+	}
+}
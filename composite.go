@@ -0,0 +1,202 @@
+package structconfig
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// compositeTags are the tags that may appear as options of the composite
+// tag under their own names. The names, env, flag, short, and desc options
+// stand for the tags configured in Options.Tags.
+var compositeTags = []string{
+	tagDefault, tagDefaultFunc, tagDefaultJSON, tagDefaultInline, tagBuildInfo, tagRuntime,
+	tagRequired, tagRequiredMsg, tagAllowEmpty, tagIgnored, tagSplitWords, tagSecret,
+	tagExpand, tagNormalize, tagTemplate, tagDurationUnit, tagFeature, tagReload,
+	tagOptional, tagGate, tagURLComponents, tagEnvNoPrefix, tagMustExist, tagNoOpt,
+	tagFlagDeprecated, tagShortDeprecated,
+}
+
+// expandCompositeTag returns the tag of field with the options of its
+// composite tag, such as config:"name=db_host,env=DB_HOST,required", added
+// as separate tags. An option naming a tag the field also has is an error.
+// A bare "-" stands for ignored.
+func (s *StructConfig) expandCompositeTag(field reflect.StructField) (reflect.StructTag, error) {
+	if s.options.Tags.CompositeTag == "" || s.options.Tags.CompositeTag == skipTagValue {
+		return field.Tag, nil
+	}
+
+	text, ok := field.Tag.Lookup(s.options.Tags.CompositeTag)
+	if !ok {
+		return field.Tag, nil
+	}
+
+	// Like json:"-", a bare "-" skips the field.
+	if text == skipTagValue {
+		text = tagIgnored
+	}
+
+	opts, err := splitCompositeTag(text)
+	if err != nil {
+		return "", fmt.Errorf("bad %s tag for field %s: %w", s.options.Tags.CompositeTag, field.Name, err)
+	}
+
+	tag := string(field.Tag)
+
+	for _, opt := range opts {
+		name, err := s.compositeTagName(opt[0])
+		if err != nil {
+			return "", fmt.Errorf("bad %s tag for field %s: %w", s.options.Tags.CompositeTag, field.Name, err)
+		}
+
+		if _, dup := field.Tag.Lookup(name); dup {
+			return "", fmt.Errorf("field %s sets %s in both the %s tag and a %s tag", field.Name, opt[0], s.options.Tags.CompositeTag, name)
+		}
+
+		tag += " " + name + ":" + strconv.Quote(opt[1])
+	}
+
+	return reflect.StructTag(strings.TrimSpace(tag)), nil
+}
+
+// compositeTagName returns the tag a composite tag option stands for.
+func (s *StructConfig) compositeTagName(opt string) (string, error) {
+	switch opt {
+	case "name":
+		return s.options.Tags.FileTag, nil
+	case "env":
+		return s.options.Tags.EnvTag, nil
+	case "flag":
+		return s.options.Tags.FlagTag, nil
+	case "short":
+		return s.options.Tags.ShortTag, nil
+	case "desc":
+		return s.options.Tags.DescTag, nil
+	}
+
+	if slices.Contains(compositeTags, opt) || isProfileTag(opt) {
+		return opt, nil
+	}
+
+	return "", fmt.Errorf("unknown option %q", opt)
+}
+
+// splitCompositeTag splits a composite tag into key and value pairs. A bare
+// key, such as required, has the value "true". Values holding commas are
+// quoted with single quotes: normalize='trim,lower'.
+func splitCompositeTag(text string) ([][2]string, error) {
+	var (
+		opts   [][2]string
+		item   strings.Builder
+		quoted bool
+	)
+
+	flush := func() error {
+		key, val, hasVal := strings.Cut(item.String(), "=")
+		item.Reset()
+
+		key = strings.TrimSpace(key)
+		if key == "" {
+			if hasVal {
+				return fmt.Errorf("option without a name")
+			}
+
+			return nil
+		}
+
+		if !hasVal {
+			val = "true"
+		}
+
+		opts = append(opts, [2]string{key, val})
+
+		return nil
+	}
+
+	for _, r := range text {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		default:
+			item.WriteRune(r)
+		}
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+// compositeNameHookFunc renames the keys of a map decoded into a struct from
+// the names set by composite tags to the field names, since mapstructure
+// only reads the file tag.
+func (s *StructConfig) compositeNameHookFunc() func(reflect.Type, reflect.Type, any) (any, error) {
+	return func(_, t reflect.Type, data any) (any, error) {
+		m, ok := data.(map[string]any)
+		if !ok || t.Kind() != reflect.Struct {
+			return data, nil
+		}
+
+		renames := make(map[string]string)
+		s.compositeNames(t, renames)
+
+		if len(renames) == 0 {
+			return data, nil
+		}
+
+		out := maps.Clone(m)
+
+		for k, v := range m {
+			if field, ok := renames[s.foldKey(k)]; ok {
+				delete(out, k)
+				out[field] = v
+			}
+		}
+
+		return out, nil
+	}
+}
+
+// compositeNames adds the names set by composite tags on the fields of t,
+// and of the structs embedded in it, to renames.
+func (s *StructConfig) compositeNames(t reflect.Type, renames map[string]string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		ft := field.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if field.Anonymous && ft.Kind() == reflect.Struct {
+			s.compositeNames(ft, renames)
+			continue
+		}
+
+		if _, ok := field.Tag.Lookup(s.options.Tags.FileTag); ok || !field.IsExported() {
+			continue
+		}
+
+		tag, err := s.expandCompositeTag(field)
+		if err != nil {
+			continue
+		}
+
+		if name, ok := tag.Lookup(s.options.Tags.FileTag); ok && name != "" {
+			renames[s.foldKey(name)] = field.Name
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package structconfig
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	defaultFuncsMu sync.RWMutex
+	defaultFuncs   = map[string]func() string{
+		"hostname": hostnameDefault,
+		"numcpu":   func() string { return strconv.Itoa(runtime.NumCPU()) },
+	}
+)
+
+// RegisterDefaultFunc registers fn under name for use in default_func tags.
+// The function is called once per Process, when the struct is inspected, and
+// its result is used like a static default tag. Registering an existing name
+// replaces it; a nil fn removes the name.
+//
+// The built-in functions are "hostname" and "numcpu".
+func RegisterDefaultFunc(name string, fn func() string) {
+	defaultFuncsMu.Lock()
+	defer defaultFuncsMu.Unlock()
+
+	if fn == nil {
+		delete(defaultFuncs, name)
+		return
+	}
+
+	defaultFuncs[name] = fn
+}
+
+// computedDefault returns the default produced by the field's default_func,
+// buildinfo, or runtime tag, or its static default tag when none is set.
+func computedDefault(field reflect.StructField) (string, error) {
+	if fact, ok := field.Tag.Lookup(tagRuntime); ok {
+		return runtimeDefault(field, fact)
+	}
+
+	if key, ok := field.Tag.Lookup(tagBuildInfo); ok {
+		return buildInfoDefault(field, key)
+	}
+
+	name, ok := field.Tag.Lookup(tagDefaultFunc)
+	if !ok {
+		return field.Tag.Get(tagDefault), nil
+	}
+
+	if _, ok = field.Tag.Lookup(tagDefault); ok {
+		return "", fmt.Errorf("field %s has both %s and %s tags", field.Name, tagDefault, tagDefaultFunc)
+	}
+
+	defaultFuncsMu.RLock()
+	fn, ok := defaultFuncs[name]
+	defaultFuncsMu.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("unknown %s %q for field %s", tagDefaultFunc, name, field.Name)
+	}
+
+	return fn(), nil
+}
+
+func hostnameDefault() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+
+	return name
+}
+
+// runtimeDefault returns the value of a runtime tag: the hostname, pid,
+// numcpu, goos, or goarch of the running process.
+func runtimeDefault(field reflect.StructField, fact string) (string, error) {
+	for _, other := range []string{tagDefault, tagDefaultFunc, tagBuildInfo} {
+		if _, ok := field.Tag.Lookup(other); ok {
+			return "", fmt.Errorf("field %s has both %s and %s tags", field.Name, other, tagRuntime)
+		}
+	}
+
+	switch fact {
+	case "hostname":
+		return hostnameDefault(), nil
+	case "pid":
+		return strconv.Itoa(os.Getpid()), nil
+	case "numcpu":
+		return strconv.Itoa(runtime.NumCPU()), nil
+	case "goos":
+		return runtime.GOOS, nil
+	case "goarch":
+		return runtime.GOARCH, nil
+	default:
+		return "", fmt.Errorf("bad %s tag value %q for field %s: want hostname, pid, numcpu, goos, or goarch", tagRuntime, fact, field.Name)
+	}
+}
+
+// checkDefault checks that the field's default and its default_<profile>
+// tags decode into the field type, after applying its duration unit, whichever
+// profile is active. Secret references are resolved later and are not
+// checked.
+func (s *StructConfig) checkDefault(info varInfo) error {
+	if def, ok := info.defaultRaw(); ok {
+		if err := s.checkDefaultValue(info, def); err != nil {
+			return fmt.Errorf("bad default %q: %w", info.Default, err)
+		}
+	}
+
+	for _, key := range structTagKeys(info.tag) {
+		if def := info.tag.Get(key); isProfileTag(key) && def != "" {
+			if err := s.checkDefaultValue(info, def); err != nil {
+				return fmt.Errorf("bad %s %q: %w", key, def, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *StructConfig) checkDefaultValue(info varInfo, def any) error {
+	if str, isStr := def.(string); isStr {
+		if _, _, isRef := lookupSecretProvider(str); isRef {
+			return nil
+		}
+	}
+
+	if info.durationUnit != 0 {
+		def = applyDurationUnit(def, info.durationUnit)
+	}
+
+	_, err := s.decodeValue(def, info.typ)
+
+	return err
+}
+
+// checkDefaults runs checkDefault on every field, naming the fields by their
+// Go path, so a bad default fails Process before any source is read.
+func (s *StructConfig) checkDefaults(spec any) error {
+	var errs []error
+
+	for _, info := range s.infos {
+		if err := s.checkDefault(info); err != nil {
+			errs = append(errs, fmt.Errorf("field %s(%s): %w", fieldPath(reflect.TypeOf(spec).Elem(), info.index), info.Key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// defaultRaw returns the value seeded into the merged map for the field, if
+// it has a default.
+func (v varInfo) defaultRaw() (any, bool) {
+	if v.defaultValue != nil {
+		return v.defaultValue, true
+	}
+
+	return v.Default, v.Default != ""
+}
+
+// setJSONDefault parses the field's default_json tag into info.defaultValue.
+func setJSONDefault(info *varInfo, field reflect.StructField) error {
+	text, ok := field.Tag.Lookup(tagDefaultJSON)
+	if !ok {
+		return nil
+	}
+
+	for _, other := range []string{tagDefault, tagDefaultFunc} {
+		if _, ok = field.Tag.Lookup(other); ok {
+			return fmt.Errorf("field %s has both %s and %s tags", field.Name, other, tagDefaultJSON)
+		}
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return fmt.Errorf("bad %s tag value for field %s: %w", tagDefaultJSON, field.Name, err)
+	}
+
+	info.Default = text
+	info.defaultValue = value
+
+	return nil
+}
+
+// setInlineDefault parses the field's default_inline tag, a config fragment
+// in the format of configType, into info.defaultValue.
+func setInlineDefault(info *varInfo, field reflect.StructField, configType string) error {
+	text, ok := field.Tag.Lookup(tagDefaultInline)
+	if !ok {
+		return nil
+	}
+
+	for _, other := range []string{tagDefault, tagDefaultFunc, tagDefaultJSON} {
+		if _, ok = field.Tag.Lookup(other); ok {
+			return fmt.Errorf("field %s has both %s and %s tags", field.Name, other, tagDefaultInline)
+		}
+	}
+
+	if kind := indirectKind(field.Type); kind != reflect.Struct && kind != reflect.Map {
+		return fmt.Errorf("%s tag on field %s requires a struct or map field", tagDefaultInline, field.Name)
+	}
+
+	value, err := unmarshalConfig(configType, []byte(text))
+	if err != nil {
+		return fmt.Errorf("bad %s tag value for field %s: %w", tagDefaultInline, field.Name, err)
+	}
+
+	info.Default = displayDefault(value)
+	info.defaultValue = value
+
+	return nil
+}
+
+// applyStructDefault distributes the object default of a struct field over
+// the fields gathered from it. The struct's default takes precedence over the
+// tags of its fields.
+func (s *StructConfig) applyStructDefault(parent varInfo, prefix string, infos []varInfo) error {
+	if parent.defaultValue == nil {
+		return nil
+	}
+
+	obj, ok := parent.defaultValue.(map[string]any)
+	if !ok {
+		return fmt.Errorf("default for struct field %s must be an object", parent.Name)
+	}
+
+	return s.applyObjectDefault(parent.Name, prefix, obj, infos)
+}
+
+func (s *StructConfig) applyObjectDefault(name, prefix string, obj map[string]any, infos []varInfo) error {
+	for k, v := range obj {
+		key := s.keyPath(prefix, s.foldKey(k))
+
+		i := slices.IndexFunc(infos, func(info varInfo) bool { return info.Key == key })
+		if i >= 0 {
+			infos[i].defaultValue = v
+			infos[i].Default = displayDefault(v)
+
+			continue
+		}
+
+		nested, isObj := v.(map[string]any)
+		if !isObj || !slices.ContainsFunc(infos, func(info varInfo) bool { return strings.HasPrefix(info.Key, key+s.options.KeyDelimiter) }) {
+			return fmt.Errorf("default for field %s sets unknown key %q", name, k)
+		}
+
+		if err := s.applyObjectDefault(name, key, nested, infos); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// displayDefault renders a structured default for help and generated files.
+func displayDefault(v any) string {
+	if str, ok := v.(string); ok {
+		return str
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(data)
+}
+
+// setPrepopulatedDefault uses a non-zero value already present in the spec
+// field as its default, in place of the field's default tags. Non-nil
+// pointers count through the value they point to. Nested structs are handled
+// field by field.
+func setPrepopulatedDefault(info *varInfo, f reflect.Value) {
+	for f.Kind() == reflect.Pointer {
+		if f.IsNil() {
+			return
+		}
+
+		f = f.Elem()
+	}
+
+	if f.IsZero() || f.Kind() == reflect.Struct && !isScalarType(f.Type()) {
+		return
+	}
+
+	info.defaultValue = defaultFromValue(f)
+	info.prefilled = true
+
+	if text, ok := valueText(f); ok {
+		info.Default = text
+	} else {
+		info.Default = displayDefault(info.defaultValue)
+	}
+}
+
+// defaultFromValue converts a Go value into the form of a merged map entry:
+// scalar types become their text and string-keyed maps become map[string]any
+// so that they merge with config file entries.
+func defaultFromValue(f reflect.Value) any {
+	if isScalarType(f.Type()) {
+		if text, ok := valueText(f); ok {
+			return text
+		}
+	}
+
+	if f.Kind() == reflect.Map && f.Type().Key().Kind() == reflect.String {
+		m := make(map[string]any, f.Len())
+
+		iter := f.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = defaultFromValue(iter.Value())
+		}
+
+		return m
+	}
+
+	return f.Interface()
+}
+
+// valueText returns the text form of f through encoding.TextMarshaler or
+// fmt.Stringer, including methods on its pointer type.
+func valueText(f reflect.Value) (string, bool) {
+	v := f.Interface()
+	if f.CanAddr() {
+		v = f.Addr().Interface()
+	}
+
+	switch val := v.(type) {
+	case encoding.TextMarshaler:
+		text, err := val.MarshalText()
+		if err != nil {
+			return "", false
+		}
+
+		return string(text), true
+	case fmt.Stringer:
+		return val.String(), true
+	default:
+		return "", false
+	}
+}
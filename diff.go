@@ -0,0 +1,209 @@
+package structconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Change describes a config key whose value differs between two configurations.
+type Change struct {
+	Key string
+	Old any
+	New any
+}
+
+// DiffDefaults returns the keys whose effective value after Process differs
+// from the value the field would have from its default tag alone.
+func (s *StructConfig) DiffDefaults() ([]Change, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.diffDefaults(s.merged)
+}
+
+func (s *StructConfig) diffDefaults(merged map[string]any) ([]Change, error) {
+	defaults := make(map[string]any, len(s.infos))
+
+	for _, info := range s.infos {
+		if def, ok := info.defaultRaw(); ok {
+			defaults[info.Key] = def
+		}
+	}
+
+	return s.diffMerged(defaults, merged)
+}
+
+// diffMerged compares two merged value maps key by key after decoding each
+// value into its field type. Keys missing from a map compare as zero values.
+func (s *StructConfig) diffMerged(oldMerged, newMerged map[string]any) ([]Change, error) {
+	var changes []Change
+
+	for _, info := range s.infos {
+		oldRaw, oldOk := oldMerged[info.Key]
+		newRaw, newOk := newMerged[info.Key]
+
+		if !oldOk && !newOk {
+			continue
+		}
+
+		oldVal, err := s.decodeMergedValue(oldRaw, oldOk, info)
+		if err != nil {
+			return nil, err
+		}
+
+		newVal, err := s.decodeMergedValue(newRaw, newOk, info)
+		if err != nil {
+			return nil, err
+		}
+
+		if !equalValues(oldVal, newVal) {
+			changes = append(changes, Change{Key: info.Key, Old: oldVal, New: newVal})
+		}
+	}
+
+	return changes, nil
+}
+
+func (s *StructConfig) decodeMergedValue(raw any, ok bool, info varInfo) (any, error) {
+	if !ok {
+		return reflect.Zero(info.typ).Interface(), nil
+	}
+
+	v, err := s.decodeValue(raw, info.typ)
+	if err != nil {
+		return nil, fmt.Errorf("decode value for key %q: %w", info.Key, err)
+	}
+
+	return v, nil
+}
+
+// Diff compares two specs of the same struct type field by field and returns
+// the config keys whose values differ, e.g. to log exactly what changed
+// between two processed configurations. Reload reports its changes the same
+// way. Keys follow the default Options. Nil and empty slices and maps compare
+// equal. Diff returns nil when oldSpec and newSpec are not pointers to
+// structs of one type, or the type is not a valid spec.
+func Diff(oldSpec, newSpec any) []Change {
+	oldV, newV := reflect.ValueOf(oldSpec), reflect.ValueOf(newSpec)
+	if oldV.Kind() != reflect.Pointer || oldV.Elem().Kind() != reflect.Struct || oldV.Type() != newV.Type() || oldV.IsNil() || newV.IsNil() {
+		return nil
+	}
+
+	// The keys are gathered from a zero spec, since gatherInfo allocates
+	// nil struct pointers.
+	infos, err := NewStructConfig(nil).gatherInfo("", "", "", nil, reflect.New(oldV.Type().Elem()).Interface())
+	if err != nil {
+		return nil
+	}
+
+	return diffSpecs(infos, oldV.Elem(), newV.Elem())
+}
+
+// diffSpecs compares the fields of infos in two spec values.
+func diffSpecs(infos []varInfo, oldV, newV reflect.Value) []Change {
+	var changes []Change
+
+	for _, info := range infos {
+		oldVal := lookupField(oldV, info.index, info.typ)
+		newVal := lookupField(newV, info.index, info.typ)
+
+		if !equalValues(oldVal, newVal) {
+			changes = append(changes, Change{Key: info.Key, Old: oldVal, New: newVal})
+		}
+	}
+
+	return changes
+}
+
+// lookupField returns the value of the nested field of v at index, or the
+// zero value of typ when a struct pointer along the way is nil.
+func lookupField(v reflect.Value, index []int, typ reflect.Type) any {
+	for _, i := range index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Zero(typ).Interface()
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(i)
+	}
+
+	return v.Interface()
+}
+
+func (s *StructConfig) processDiffDefaultsFlag(merged map[string]any) (string, error) {
+	if s.options.FlagNames.DiffDefaults == skipBuiltInFlagValue {
+		return "", nil
+	}
+
+	printDiff, err := s.flags.GetBool(s.options.FlagNames.DiffDefaults)
+	if err != nil {
+		return "", err
+	}
+
+	if !printDiff {
+		return "", nil
+	}
+
+	changes, err := s.diffDefaults(merged)
+	if err != nil {
+		return "", err
+	}
+
+	return s.formatChanges(changes), ErrDiffDefaultsCalled
+}
+
+// formatChanges renders one "key: old -> new" line per change, redacting
+// the values of secret fields.
+func (s *StructConfig) formatChanges(changes []Change) string {
+	secret := make(map[string]bool, len(s.infos))
+	for _, info := range s.infos {
+		secret[info.Key] = info.Secret
+	}
+
+	var b strings.Builder
+
+	for _, c := range changes {
+		oldVal, newVal := formatValue(c.Old), formatValue(c.New)
+		if secret[c.Key] {
+			oldVal, newVal = redactedValue, redactedValue
+		}
+
+		fmt.Fprintf(&b, "%s: %s -> %s\n", c.Key, oldVal, newVal)
+	}
+
+	return b.String()
+}
+
+func formatValue(v any) string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return "<nil>"
+		}
+
+		return fmt.Sprint(rv.Elem().Interface())
+	}
+
+	return fmt.Sprint(v)
+}
+
+// equalValues compares decoded values, treating nil and empty slices and maps
+// as equal.
+func equalValues(a, b any) bool {
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+
+	if av.IsValid() && bv.IsValid() && av.Type() == bv.Type() {
+		switch av.Kind() {
+		case reflect.Slice, reflect.Map:
+			if av.Len() == 0 && bv.Len() == 0 {
+				return true
+			}
+		}
+	}
+
+	return reflect.DeepEqual(a, b)
+}
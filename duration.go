@@ -0,0 +1,126 @@
+package structconfig
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// dayWeekRegexp matches the day and week components that time.ParseDuration
+// does not know, e.g. the "1d" of "1d12h".
+var dayWeekRegexp = regexp.MustCompile(`([0-9]*\.?[0-9]+)([dw])`)
+
+// parseDuration is time.ParseDuration extended with the units d (24h) and
+// w (7d), so values like "1d", "2w", and "1d12h" are accepted.
+func parseDuration(s string) (time.Duration, error) {
+	expanded := dayWeekRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		parts := dayWeekRegexp.FindStringSubmatch(m)
+
+		n, _ := strconv.ParseFloat(parts[1], 64)
+		if parts[2] == "w" {
+			n *= 7
+		}
+
+		return strconv.FormatFloat(n*24, 'f', -1, 64) + "h"
+	})
+
+	d, err := time.ParseDuration(expanded)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	return d, nil
+}
+
+// parseDurationUnit parses a duration_unit tag such as "s" or "ms" into the
+// duration of one unit. The tag is accepted on time.Duration fields.
+func parseDurationUnit(field reflect.StructField, tag string) (time.Duration, error) {
+	typ := field.Type
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if typ != durationType {
+		return 0, fmt.Errorf("%s tag on field %s requires a time.Duration field, got %s", tagDurationUnit, field.Name, field.Type)
+	}
+
+	unit, err := parseDuration("1" + tag)
+	if err != nil {
+		return 0, fmt.Errorf("bad %s tag value %q for field %s: want a unit such as ms, s, m, h, or d", tagDurationUnit, tag, field.Name)
+	}
+
+	return unit, nil
+}
+
+// applyDurationUnit converts a bare number, or a string holding one, to a
+// time.Duration in unit. Other values are returned unchanged.
+func applyDurationUnit(v any, unit time.Duration) any {
+	var n float64
+
+	switch val := v.(type) {
+	case int:
+		n = float64(val)
+	case int64:
+		n = float64(val)
+	case uint64:
+		n = float64(val)
+	case float64:
+		n = val
+	case string:
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return v
+		}
+
+		n = f
+	default:
+		return v
+	}
+
+	return time.Duration(n * float64(unit))
+}
+
+// durationHookFunc decodes strings into time.Duration with parseDuration.
+func durationHookFunc() func(reflect.Type, reflect.Type, any) (any, error) {
+	return func(f, t reflect.Type, data any) (any, error) {
+		if t != durationType || f.Kind() != reflect.String || f == jsonNumberType {
+			return data, nil
+		}
+
+		return parseDuration(reflect.ValueOf(data).String())
+	}
+}
+
+// durationValue is the pflag.Value for time.Duration fields. It accepts the
+// d and w units and, when unit is set by duration_unit, bare numbers. Its
+// type name matches pflag's own duration flags, so GetDuration reads it.
+type durationValue struct {
+	d    time.Duration
+	unit time.Duration
+}
+
+func (v *durationValue) String() string { return v.d.String() }
+
+func (v *durationValue) Set(s string) error {
+	if v.unit != 0 {
+		if d, ok := applyDurationUnit(s, v.unit).(time.Duration); ok {
+			v.d = d
+			return nil
+		}
+	}
+
+	d, err := parseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	v.d = d
+
+	return nil
+}
+
+func (v *durationValue) Type() string { return "duration" }
@@ -0,0 +1,159 @@
+package structconfig
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const encryptedPrefix = "enc:AES256:"
+
+// ErrNoKeyProvider is returned when a config file holds an encrypted value but
+// Options.KeyProvider is not set.
+var ErrNoKeyProvider = errors.New("encrypted value found but no key provider is configured")
+
+// KeyProvider supplies the 32-byte key used to decrypt inline encrypted values.
+// Implementations may read the key from a static source, a KMS, or a keyring.
+type KeyProvider interface {
+	Key(ctx context.Context) ([]byte, error)
+}
+
+// StaticKey is a KeyProvider that always returns the same key.
+type StaticKey []byte
+
+// Key returns the static key.
+func (k StaticKey) Key(context.Context) ([]byte, error) {
+	return k, nil
+}
+
+// EncryptValue encrypts plaintext with a 32-byte key using AES-256-GCM and
+// returns it in the enc:AES256:<base64> form accepted in config files.
+func EncryptValue(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptValue(key []byte, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("AES256 key must be 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// decryptValues replaces encrypted strings in a decoded config tree in place.
+// The key is requested from the provider only when an encrypted value is found.
+func (s *StructConfig) decryptValues(data map[string]any) error {
+	var key []byte
+
+	var walk func(path string, v any) (any, error)
+
+	walk = func(path string, v any) (any, error) {
+		switch val := v.(type) {
+		case string:
+			if !strings.HasPrefix(val, encryptedPrefix) {
+				return val, nil
+			}
+
+			if key == nil {
+				if s.options.KeyProvider == nil {
+					return nil, fmt.Errorf("key %q: %w", path, ErrNoKeyProvider)
+				}
+
+				k, err := s.options.KeyProvider.Key(s.options.Context)
+				if err != nil {
+					return nil, fmt.Errorf("key %q: get decryption key: %w", path, err)
+				}
+
+				key = k
+			}
+
+			plain, err := decryptValue(key, val)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", path, err)
+			}
+
+			return plain, nil
+		case map[string]any:
+			for k, item := range val {
+				out, err := walk(joinKey(path, k), item)
+				if err != nil {
+					return nil, err
+				}
+
+				val[k] = out
+			}
+
+			return val, nil
+		case []any:
+			for i, item := range val {
+				out, err := walk(fmt.Sprintf("%s[%d]", path, i), item)
+				if err != nil {
+					return nil, err
+				}
+
+				val[i] = out
+			}
+
+			return val, nil
+		default:
+			return v, nil
+		}
+	}
+
+	_, err := walk("", data)
+
+	return err
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "." + key
+}
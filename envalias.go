@@ -0,0 +1,81 @@
+package structconfig
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// splitEnvTag splits an env tag such as "NEW_NAME,OLD_NAME" into the primary
+// env var and its aliases. An empty primary, as in ",OLD_NAME", keeps the
+// generated name.
+func splitEnvTag(tag string) (string, []string) {
+	primary, rest, found := strings.Cut(tag, ",")
+	if !found {
+		return tag, nil
+	}
+
+	var aliases []string
+
+	for _, alias := range strings.Split(rest, ",") {
+		if alias = strings.TrimSpace(alias); alias != "" && alias != skipTagValue {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	return strings.TrimSpace(primary), aliases
+}
+
+// envNames returns the env vars bound to the field, primary first.
+func (v varInfo) envNames() []string {
+	if v.Env == "" || v.Env == skipTagValue {
+		return nil
+	}
+
+	return append([]string{v.Env}, v.envAliases...)
+}
+
+// boundEnvNames returns envNames plus the env vars of the url_components
+// struct the field belongs to.
+func (v varInfo) boundEnvNames() []string {
+	if v.urlSource == nil {
+		return v.envNames()
+	}
+
+	return append(v.envNames(), v.urlSource.envNames()...)
+}
+
+// lookupEnv returns the first env var bound to the field that is set, trying
+// the primary name before the aliases in tag order.
+func lookupEnv(info varInfo) (name, val string, ok bool) {
+	for _, name = range info.envNames() {
+		if val, ok = os.LookupEnv(name); ok {
+			return name, val, true
+		}
+	}
+
+	return "", "", false
+}
+
+// applyEnvAliases adds the env vars of Options.EnvAliases to the aliases of
+// the fields with their keys, in name order after the env tag aliases.
+func (s *StructConfig) applyEnvAliases(infos []varInfo) error {
+	for _, name := range slices.Sorted(maps.Keys(s.options.EnvAliases)) {
+		key := s.foldKey(s.options.EnvAliases[name])
+
+		i := slices.IndexFunc(infos, func(info varInfo) bool { return info.Key == key })
+		if i < 0 {
+			return fmt.Errorf("env alias %s: no field has key %q", name, key)
+		}
+
+		if infos[i].Env == "" || infos[i].Env == skipTagValue {
+			return fmt.Errorf("env alias %s: field %s(%s) has no env var", name, infos[i].Name, key)
+		}
+
+		infos[i].envAliases = append(infos[i].envAliases, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package structconfig
+
+import (
+	"context"
+	"maps"
+	"os"
+	"time"
+)
+
+// envWatcher is the Watcher started by Watch when Options.EnvPollInterval is
+// set. It re-reads the env vars bound to fields and notifies when one of them
+// was set, changed, or unset.
+type envWatcher struct {
+	s        *StructConfig
+	interval time.Duration
+}
+
+func (w envWatcher) Watch(ctx context.Context, notify func()) error {
+	last := w.s.boundEnv()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if env := w.s.boundEnv(); !maps.Equal(env, last) {
+			last = env
+			notify()
+		}
+	}
+}
+
+// boundEnv returns the values of the env vars bound to fields that are set.
+func (s *StructConfig) boundEnv() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	env := make(map[string]string)
+
+	for _, info := range s.infos {
+		for _, name := range info.boundEnvNames() {
+			if val, ok := os.LookupEnv(name); ok {
+				env[name] = val
+			}
+		}
+	}
+
+	return env
+}
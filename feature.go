@@ -0,0 +1,91 @@
+package structconfig
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// FeatureProvider evaluates feature flags for fields with a feature tag. Its
+// methods match those of an OpenFeature client minus the evaluation context,
+// so a client is bridged with a small adapter. defaultValue is the value the
+// field has from the static configuration; it is returned on errors.
+type FeatureProvider interface {
+	BooleanValue(ctx context.Context, flag string, defaultValue bool) (bool, error)
+	StringValue(ctx context.Context, flag string, defaultValue string) (string, error)
+}
+
+// checkFeatureTag checks that a feature tag is on a bool or string field.
+func checkFeatureTag(field reflect.StructField, tag string) error {
+	if kind := indirectKind(field.Type); kind != reflect.Bool && kind != reflect.String {
+		return fmt.Errorf("%s tag on field %s requires a bool or string field, got %s", tagFeature, field.Name, field.Type)
+	}
+
+	if tag == "" {
+		return fmt.Errorf("%s tag on field %s requires a flag name", tagFeature, field.Name)
+	}
+
+	return nil
+}
+
+// resolveFeatures replaces the values of fields with a feature tag by the
+// flag values from Options.FeatureProvider, passing the static value as the
+// default. Without a provider the static values are kept. Evaluation errors
+// are reported through Options.OnWarning and also keep the static value.
+// The evaluated values are kept in s.featureData for source attribution.
+func (s *StructConfig) resolveFeatures(m map[string]any) {
+	s.featureData = nil
+
+	for _, info := range s.infos {
+		flag := info.tag.Get(tagFeature)
+		if flag == "" || s.options.FeatureProvider == nil {
+			continue
+		}
+
+		ctx := s.providerContext()
+
+		var (
+			val any
+			err error
+		)
+
+		static, ok := m[info.Key]
+
+		if indirectKind(info.typ) == reflect.Bool {
+			def := false
+			if ok {
+				def, _ = strconv.ParseBool(fmt.Sprint(static))
+			}
+
+			val, err = s.options.FeatureProvider.BooleanValue(ctx, flag, def)
+		} else {
+			def := ""
+			if ok {
+				def = fmt.Sprint(static)
+			}
+
+			val, err = s.options.FeatureProvider.StringValue(ctx, flag, def)
+		}
+
+		if err != nil {
+			s.audit(AuditFeature, info.Key, "evaluating %q failed: %v", flag, err)
+			s.warn(Warning{
+				Kind:    WarningFeatureError,
+				Key:     info.Key,
+				Message: fmt.Sprintf("evaluate feature flag %q: %v", flag, err),
+			})
+
+			continue
+		}
+
+		m[info.Key] = val
+		s.audit(AuditFeature, info.Key, "evaluated %q", flag)
+
+		if s.featureData == nil {
+			s.featureData = make(map[string]any)
+		}
+
+		s.featureData[info.Key] = val
+	}
+}
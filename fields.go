@@ -0,0 +1,90 @@
+package structconfig
+
+import (
+	"reflect"
+	"strings"
+)
+
+// FieldInfo describes a configurable field of the processed spec.
+type FieldInfo struct {
+	// Name is the Go field path, e.g. "DB.Host".
+	Name string
+	// Key is the dot-separated config file key.
+	Key string
+	// Env is the bound environment variable, empty when env binding is disabled.
+	Env string
+	// Flag is the long flag name without dashes, empty when the field has no flag.
+	Flag string
+	// Short is the one-letter flag shorthand, if any.
+	Short       string
+	Type        reflect.Type
+	Default     string
+	Required    bool
+	Description string
+	Secret      bool
+}
+
+// Fields returns metadata for every configurable field in declaration order.
+// It is available once Process has inspected the spec, including when
+// Process returned a control-flow error such as ErrHelpRequested, and returns
+// nil before that.
+func (s *StructConfig) Fields() []FieldInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.spec == nil {
+		return nil
+	}
+
+	specType := reflect.TypeOf(s.spec).Elem()
+	fields := make([]FieldInfo, 0, len(s.infos))
+
+	for _, info := range s.infos {
+		fi := FieldInfo{
+			Name:        fieldPath(specType, info.index),
+			Key:         info.Key,
+			Env:         info.Env,
+			Flag:        info.Flag,
+			Short:       info.ShortFlag,
+			Type:        info.typ,
+			Default:     info.Default,
+			Required:    info.Required,
+			Description: info.Description,
+			Secret:      info.Secret,
+		}
+
+		if fi.Env == skipTagValue {
+			fi.Env = ""
+		}
+
+		if fi.Flag == skipTagValue {
+			fi.Flag = ""
+			fi.Short = ""
+		}
+
+		fields = append(fields, fi)
+	}
+
+	return fields
+}
+
+// fieldPath returns the dotted Go field names along index, skipping the names
+// of embedded structs whose fields are promoted.
+func fieldPath(typ reflect.Type, index []int) string {
+	var names []string
+
+	for n, i := range index {
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+
+		f := typ.Field(i)
+		if !f.Anonymous || n == len(index)-1 {
+			names = append(names, f.Name)
+		}
+
+		typ = f.Type
+	}
+
+	return strings.Join(names, ".")
+}
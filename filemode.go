@@ -0,0 +1,62 @@
+package structconfig
+
+import (
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var fileModeType = reflect.TypeFor[fs.FileMode]()
+
+// parseFileMode parses permission bits written in octal, such as "0640",
+// "640", or "0o640".
+func parseFileMode(s string) (fs.FileMode, error) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "0o"), "0O")
+
+	n, err := strconv.ParseUint(digits, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file mode %q: want octal permission bits such as 0640", s)
+	}
+
+	return fs.FileMode(n), nil
+}
+
+// formatFileMode formats the permission and special bits of m in octal.
+func formatFileMode(m fs.FileMode) string {
+	return fmt.Sprintf("%#o", uint32(m))
+}
+
+// fileModeHookFunc decodes octal strings into fs.FileMode (and os.FileMode)
+// fields. Numbers are used as they are, since TOML and YAML already read
+// 0o640 and 0640 as octal.
+func fileModeHookFunc() func(reflect.Type, reflect.Type, any) (any, error) {
+	return func(f, t reflect.Type, data any) (any, error) {
+		if t != fileModeType || f.Kind() != reflect.String || f == jsonNumberType {
+			return data, nil
+		}
+
+		return parseFileMode(reflect.ValueOf(data).String())
+	}
+}
+
+// fileModeValue is the pflag.Value for fs.FileMode fields, so flags take
+// octal modes instead of the decimal numbers of a plain uint32 flag. The
+// flag's text is decoded by fileModeHookFunc.
+type fileModeValue fs.FileMode
+
+func (v *fileModeValue) String() string { return formatFileMode(fs.FileMode(*v)) }
+
+func (v *fileModeValue) Set(s string) error {
+	m, err := parseFileMode(s)
+	if err != nil {
+		return err
+	}
+
+	*v = fileModeValue(m)
+
+	return nil
+}
+
+func (v *fileModeValue) Type() string { return "fs.FileMode" }
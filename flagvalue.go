@@ -0,0 +1,107 @@
+package structconfig
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/spf13/pflag"
+)
+
+var (
+	flagValuesMu sync.RWMutex
+	flagValues   = map[reflect.Type]func() pflag.Value{}
+)
+
+// RegisterFlagValue registers newValue as the flag implementation for fields
+// of type typ, which must not be a pointer type. Types whose pointer already
+// implements pflag.Value or flag.Value need no registration. Fields of the
+// type, or a pointer to it, get a flag created by newValue, and their text
+// from env vars, config files, and defaults is parsed with a fresh value's
+// Set.
+//
+// The parsed value is read back from the pflag.Value: it must be a *typ, or
+// implement Get() any returning a typ, as flag.Getter does. Registering an
+// existing type replaces it; a nil newValue removes the type.
+func RegisterFlagValue(typ reflect.Type, newValue func() pflag.Value) {
+	flagValuesMu.Lock()
+	defer flagValuesMu.Unlock()
+
+	if newValue == nil {
+		delete(flagValues, typ)
+		return
+	}
+
+	flagValues[typ] = newValue
+}
+
+var (
+	pflagValueType  = reflect.TypeFor[pflag.Value]()
+	goFlagValueType = reflect.TypeFor[flag.Value]()
+)
+
+// lookupFlagValue returns the pflag.Value constructor registered for typ or,
+// when none is, one for types whose pointer implements pflag.Value or
+// flag.Value.
+func lookupFlagValue(typ reflect.Type) (func() pflag.Value, bool) {
+	flagValuesMu.RLock()
+	newValue, ok := flagValues[typ]
+	flagValuesMu.RUnlock()
+
+	if ok {
+		return newValue, true
+	}
+
+	ptr := reflect.PointerTo(typ)
+
+	switch {
+	case ptr.Implements(pflagValueType):
+		return func() pflag.Value { return reflect.New(typ).Interface().(pflag.Value) }, true
+	case ptr.Implements(goFlagValueType):
+		return func() pflag.Value {
+			return &goFlagValue{Value: reflect.New(typ).Interface().(flag.Value), typ: typ}
+		}, true
+	default:
+		return nil, false
+	}
+}
+
+// goFlagValue adapts a standard library flag.Value, which has no Type method,
+// to pflag.Value. Type reports the Go type.
+type goFlagValue struct {
+	flag.Value
+	typ reflect.Type
+}
+
+func (v *goFlagValue) Type() string { return v.typ.String() }
+
+// parseFlagValue parses text with a value from newValue and returns a pointer
+// to the resulting typ.
+func parseFlagValue(typ reflect.Type, newValue func() pflag.Value, text string) (any, error) {
+	var v any = newValue()
+	if err := v.(pflag.Value).Set(text); err != nil {
+		return nil, err
+	}
+
+	if adapted, ok := v.(*goFlagValue); ok {
+		v = adapted.Value
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Type() == reflect.PointerTo(typ) {
+		return v, nil
+	}
+
+	if getter, ok := v.(interface{ Get() any }); ok {
+		got := reflect.ValueOf(getter.Get())
+		if got.IsValid() && got.Type().AssignableTo(typ) {
+			ptr := reflect.New(typ)
+			ptr.Elem().Set(got)
+
+			return ptr.Interface(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("flag value %T for %s must be a *%s or implement Get() any", v, typ, typ)
+}
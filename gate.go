@@ -0,0 +1,51 @@
+package structconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// enabledName is the field name that makes a bool field of a nested struct
+// its gate without a gate tag, as in tls.enabled.
+const enabledName = "enabled"
+
+// checkGateTag reports whether field, named name, is the gate of its struct:
+// it is tagged gate:"true", or it is a bool field named enabled in a nested
+// struct without a gate tag. tagged is set when the field has a gate tag.
+func checkGateTag(field reflect.StructField, name string, nested bool) (gate, tagged bool, err error) {
+	tag, ok := field.Tag.Lookup(tagGate)
+	if !ok {
+		return nested && strings.EqualFold(name, enabledName) && indirectKind(field.Type) == reflect.Bool, false, nil
+	}
+
+	gate, err = isTrue2(tag)
+	if err != nil {
+		return false, true, fmt.Errorf("bad %s tag value for field %s: %w", tagGate, field.Name, err)
+	}
+
+	if gate && indirectKind(field.Type) != reflect.Bool {
+		return false, true, fmt.Errorf("%s tag on field %s requires a bool field, got %s", tagGate, field.Name, field.Type)
+	}
+
+	return gate, true, nil
+}
+
+// disabled reports whether a gate field of a struct enclosing info is false
+// in merged, so the required and must_exist checks of info are skipped. A
+// gate that no source or default sets keeps the checks on.
+func (s *StructConfig) disabled(info varInfo, merged map[string]any) bool {
+	for _, key := range info.gates {
+		v, ok := merged[key]
+		if !ok {
+			continue
+		}
+
+		if on, err := strconv.ParseBool(fmt.Sprint(v)); err == nil && !on {
+			return true
+		}
+	}
+
+	return false
+}
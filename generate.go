@@ -0,0 +1,334 @@
+package structconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ExampleConfig renders a config file of Options.ConfigType holding the default
+// of every field of spec, as printed by --default-config.
+func ExampleConfig(prefix string, spec any, opts *Options) (string, error) {
+	s, err := inspect(prefix, spec, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return s.defaultConfig()
+}
+
+// EnvExample renders a .env.example file listing every environment variable
+// of spec with its default value. Descriptions, required fields, and secrets
+// are noted in comments; secret defaults are left out.
+func EnvExample(prefix string, spec any, opts *Options) (string, error) {
+	s, err := inspect(prefix, spec, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	for _, info := range s.envInfos() {
+		writeEnvComments(&b, info)
+		fmt.Fprintf(&b, "%s=%s\n\n", info.Env, quoteEnvValue(envExampleValue(info)))
+	}
+
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// SystemdEnvironmentFile renders a template for a systemd EnvironmentFile.
+// Required variables are listed with their default, or an empty value to be
+// filled in when they have none or are secret; the others are commented out
+// with their default, which applies while the line stays commented.
+func SystemdEnvironmentFile(prefix string, spec any, opts *Options) (string, error) {
+	s, err := inspect(prefix, spec, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	for _, info := range s.envInfos() {
+		writeEnvComments(&b, info)
+
+		if info.Required {
+			fmt.Fprintf(&b, "%s=%s\n\n", info.Env, quoteEnvValue(envExampleValue(info)))
+		} else {
+			fmt.Fprintf(&b, "#%s=%s\n\n", info.Env, quoteEnvValue(envExampleValue(info)))
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// envInfos returns the fields bound to an environment variable.
+func (s *StructConfig) envInfos() []varInfo {
+	infos := make([]varInfo, 0, len(s.infos))
+
+	for _, info := range s.infos {
+		if info.Env != "" && info.Env != skipTagValue {
+			infos = append(infos, info)
+		}
+	}
+
+	return infos
+}
+
+func writeEnvComments(b *strings.Builder, info varInfo) {
+	for _, line := range strings.Split(info.Description, "\n") {
+		if line != "" {
+			fmt.Fprintf(b, "# %s\n", line)
+		}
+	}
+
+	var notes []string
+	if info.Required {
+		notes = append(notes, "required")
+	}
+
+	if info.Secret {
+		notes = append(notes, "secret")
+	}
+
+	if len(notes) > 0 {
+		fmt.Fprintf(b, "# (%s)\n", strings.Join(notes, ", "))
+	}
+}
+
+func envExampleValue(info varInfo) string {
+	if info.Secret {
+		return ""
+	}
+
+	return info.Default
+}
+
+// quoteEnvValue double-quotes values that dotenv loaders and systemd would
+// otherwise split or interpret.
+func quoteEnvValue(v string) string {
+	if strings.ContainsAny(v, " \t\n\"'\\#$`") {
+		return strconv.Quote(v)
+	}
+
+	return v
+}
+
+// ComposeEnvironment renders the environment block of a docker-compose
+// service for spec. Optional variables are set to their defaults. Required
+// and secret variables are interpolated from the host environment as
+// ${VAR}, with required ones written as ${VAR:?...} so compose refuses to
+// start while they are unset.
+func ComposeEnvironment(prefix string, spec any, opts *Options) (string, error) {
+	s, err := inspect(prefix, spec, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	b.WriteString("environment:\n")
+
+	for _, info := range s.envInfos() {
+		for _, line := range strings.Split(info.Description, "\n") {
+			if line != "" {
+				fmt.Fprintf(&b, "  # %s\n", line)
+			}
+		}
+
+		var value string
+
+		switch {
+		case info.Required:
+			value = fmt.Sprintf("${%s:?%s is required}", info.Env, info.Env)
+		case info.Secret:
+			value = fmt.Sprintf("${%s}", info.Env)
+		default:
+			// Compose interpolates $ in values; $$ is a literal dollar sign.
+			value = strconv.Quote(strings.ReplaceAll(info.Default, "$", "$$"))
+		}
+
+		fmt.Fprintf(&b, "  %s: %s\n", info.Env, value)
+	}
+
+	return b.String(), nil
+}
+
+// MarkdownDoc renders a Markdown table documenting every field of spec: its
+// config key, env var, flag, type, default, whether it is required, and its
+// description. Secret defaults are redacted.
+func MarkdownDoc(prefix string, spec any, opts *Options) (string, error) {
+	s, err := inspect(prefix, spec, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	b.WriteString("| Key | Env | Flag | Type | Default | Required | Description |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+
+	for _, info := range s.infos {
+		var env, flag, def, required string
+
+		if info.Env != "" && info.Env != skipTagValue {
+			env = "`" + info.Env + "`"
+		}
+
+		if f := helpFlag(info); f != "" {
+			flag = "`--" + f + "`"
+			if info.ShortFlag != "" && info.ShortFlag != skipTagValue {
+				flag += " (`-" + info.ShortFlag + "`)"
+			}
+		}
+
+		switch {
+		case info.Secret && info.Default != "":
+			def = redactedValue
+		case info.Default != "":
+			def = "`" + info.Default + "`"
+		}
+
+		if info.Required {
+			required = "yes"
+		}
+
+		desc := strings.Join(strings.Fields(info.Description), " ")
+
+		cells := []string{"`" + info.Key + "`", env, flag, "`" + info.typ.String() + "`", def, required, desc}
+		for i, cell := range cells {
+			cells[i] = strings.ReplaceAll(cell, "|", "\\|")
+		}
+
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(cells, " | "))
+	}
+
+	return b.String(), nil
+}
+
+// JSONSchema renders a JSON Schema (draft 2020-12) of the config file of
+// spec, for editors and CI to check config files against. Keys nest as in
+// the file, required fields are listed as required by their object, and
+// defaults are included except for secrets, which are marked writeOnly.
+func JSONSchema(prefix string, spec any, opts *Options) (string, error) {
+	s, err := inspect(prefix, spec, opts)
+	if err != nil {
+		return "", err
+	}
+
+	root := map[string]any{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"type":       "object",
+		"properties": map[string]any{},
+	}
+
+	for _, info := range s.infos {
+		parts := strings.Split(info.Key, s.options.KeyDelimiter)
+		obj := root
+
+		for _, part := range parts[:len(parts)-1] {
+			props := obj["properties"].(map[string]any)
+
+			child, ok := props[part].(map[string]any)
+			if !ok {
+				child = map[string]any{"type": "object", "properties": map[string]any{}}
+				props[part] = child
+			}
+
+			obj = child
+		}
+
+		prop := schemaType(info.typ)
+
+		if info.Description != "" {
+			prop["description"] = info.Description
+		}
+
+		if info.Secret {
+			prop["writeOnly"] = true
+		} else if def, ok := schemaDefault(info, prop); ok {
+			prop["default"] = def
+		}
+
+		name := parts[len(parts)-1]
+		obj["properties"].(map[string]any)[name] = prop
+
+		if info.Required {
+			required, _ := obj["required"].([]string)
+			obj["required"] = append(required, name)
+		}
+	}
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(data) + "\n", nil
+}
+
+// schemaType returns the JSON Schema of the config file values of typ.
+// Durations are written as text such as "5s" or as nanoseconds, and other
+// types with a text form as strings.
+func schemaType(typ reflect.Type) map[string]any {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if typ == durationType {
+		return map[string]any{"type": []string{"string", "integer"}}
+	}
+
+	if isScalarType(typ) {
+		return map[string]any{"type": "string"}
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaType(typ.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaType(typ.Elem())}
+	case reflect.Struct:
+		return map[string]any{"type": "object"}
+	case reflect.Interface:
+		return map[string]any{}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+// schemaDefault returns the default of info in the JSON type of prop, if it
+// has one that converts.
+func schemaDefault(info varInfo, prop map[string]any) (any, bool) {
+	if info.defaultValue != nil {
+		return info.defaultValue, true
+	}
+
+	if info.Default == "" {
+		return nil, false
+	}
+
+	switch prop["type"] {
+	case "boolean":
+		v, err := strconv.ParseBool(info.Default)
+		return v, err == nil
+	case "integer":
+		v, err := strconv.ParseInt(info.Default, 10, 64)
+		return v, err == nil
+	case "number":
+		v, err := strconv.ParseFloat(info.Default, 64)
+		return v, err == nil
+	case "array", "object":
+		return nil, false
+	default:
+		return info.Default, true
+	}
+}
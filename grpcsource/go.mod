@@ -0,0 +1,11 @@
+module github.com/justakit/structconfig/grpcsource
+
+go 1.23.0
+
+require (
+	github.com/justakit/structconfig v0.0.0
+	google.golang.org/grpc v1.67.1
+	google.golang.org/protobuf v1.34.2
+)
+
+replace github.com/justakit/structconfig => ../
@@ -0,0 +1,182 @@
+// Package grpcsource provides a structconfig.StreamSource fed by a gRPC
+// server implementing the ConfigService of
+// proto/configservice/v1/config_service.proto.
+//
+// It lives in its own module so that structconfig itself does not depend on
+// gRPC. The client is built on descriptors of the service messages rather
+// than generated code, so servers are free to generate theirs from the proto
+// file into any package.
+package grpcsource
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/justakit/structconfig"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	fetchMethod     = "/structconfig.configservice.v1.ConfigService/Fetch"
+	subscribeMethod = "/structconfig.configservice.v1.ConfigService/Subscribe"
+)
+
+// The message descriptors of config_service.proto.
+var (
+	fetchRequest     protoreflect.MessageDescriptor
+	subscribeRequest protoreflect.MessageDescriptor
+	configTree       protoreflect.MessageDescriptor
+)
+
+func init() {
+	appRequest := func(name string) *descriptorpb.DescriptorProto {
+		return &descriptorpb.DescriptorProto{
+			Name: proto.String(name),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("app", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+			},
+		}
+	}
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("proto/configservice/v1/config_service.proto"),
+		Package:    proto.String("structconfig.configservice.v1"),
+		Dependency: []string{"google/protobuf/struct.proto"},
+		Syntax:     proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			appRequest("FetchRequest"),
+			appRequest("SubscribeRequest"),
+			{
+				Name: proto.String("ConfigTree"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("tree", 1, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Struct"),
+					field("revision", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				},
+			},
+		},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(fmt.Sprintf("grpcsource: build config service descriptors: %v", err))
+	}
+
+	fetchRequest = file.Messages().ByName("FetchRequest")
+	subscribeRequest = file.Messages().ByName("SubscribeRequest")
+	configTree = file.Messages().ByName("ConfigTree")
+}
+
+// field describes a singular proto3 field.
+func field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(name),
+		Number:   proto.Int32(number),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     typ.Enum(),
+	}
+
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+
+	return f
+}
+
+// New returns a StreamSource that fetches the config tree of app from the
+// ConfigService served on conn, and subscribes to its updates when the
+// config is watched. conn is typically a *grpc.ClientConn; its lifetime is
+// managed by the caller.
+func New(conn grpc.ClientConnInterface, app string) *structconfig.StreamSource {
+	return &structconfig.StreamSource{
+		Name: "grpc",
+		Fetch: func(ctx context.Context) (map[string]any, error) {
+			return fetch(ctx, conn, app)
+		},
+		Subscribe: func(ctx context.Context, update func(map[string]any)) error {
+			return subscribe(ctx, conn, app, update)
+		},
+	}
+}
+
+func fetch(ctx context.Context, conn grpc.ClientConnInterface, app string) (map[string]any, error) {
+	resp := dynamicpb.NewMessage(configTree)
+
+	if err := conn.Invoke(ctx, fetchMethod, request(fetchRequest, app), resp); err != nil {
+		return nil, fmt.Errorf("fetch config tree: %w", err)
+	}
+
+	return treeOf(resp)
+}
+
+// subscribe passes every config tree streamed by the server to update until
+// ctx is done or the stream fails.
+func subscribe(ctx context.Context, conn grpc.ClientConnInterface, app string, update func(map[string]any)) error {
+	desc := &grpc.StreamDesc{StreamName: "Subscribe", ServerStreams: true}
+
+	stream, err := conn.NewStream(ctx, desc, subscribeMethod)
+	if err != nil {
+		return fmt.Errorf("subscribe to config tree: %w", err)
+	}
+
+	if err = stream.SendMsg(request(subscribeRequest, app)); err != nil {
+		return fmt.Errorf("subscribe to config tree: %w", err)
+	}
+
+	if err = stream.CloseSend(); err != nil {
+		return fmt.Errorf("subscribe to config tree: %w", err)
+	}
+
+	for {
+		resp := dynamicpb.NewMessage(configTree)
+
+		if err = stream.RecvMsg(resp); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("receive config tree: %w", err)
+		}
+
+		data, err := treeOf(resp)
+		if err != nil {
+			return err
+		}
+
+		update(data)
+	}
+}
+
+// request returns a FetchRequest or SubscribeRequest for app.
+func request(md protoreflect.MessageDescriptor, app string) *dynamicpb.Message {
+	m := dynamicpb.NewMessage(md)
+	m.Set(md.Fields().ByName("app"), protoreflect.ValueOfString(app))
+
+	return m
+}
+
+// treeOf returns the tree of a ConfigTree, keyed like a config file.
+func treeOf(m *dynamicpb.Message) (map[string]any, error) {
+	fd := configTree.Fields().ByName("tree")
+	if !m.Has(fd) {
+		return map[string]any{}, nil
+	}
+
+	b, err := proto.Marshal(m.Get(fd).Message().Interface())
+	if err != nil {
+		return nil, fmt.Errorf("decode config tree: %w", err)
+	}
+
+	var tree structpb.Struct
+
+	if err = proto.Unmarshal(b, &tree); err != nil {
+		return nil, fmt.Errorf("decode config tree: %w", err)
+	}
+
+	return tree.AsMap(), nil
+}
@@ -0,0 +1,176 @@
+package grpcsource
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/justakit/structconfig"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// testServer serves the ConfigService without generated code: Fetch returns
+// current, and Subscribe sends current, then every tree sent on updates.
+type testServer struct {
+	current map[string]any
+	updates chan map[string]any
+	apps    chan string
+}
+
+func (s *testServer) desc() *grpc.ServiceDesc {
+	return &grpc.ServiceDesc{
+		ServiceName: "structconfig.configservice.v1.ConfigService",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Fetch",
+			Handler: func(_ any, _ context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				req := dynamicpb.NewMessage(fetchRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+
+				s.apps <- req.Get(fetchRequest.Fields().ByName("app")).String()
+
+				return testTree(s.current)
+			},
+		}},
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "Subscribe",
+			ServerStreams: true,
+			Handler: func(_ any, stream grpc.ServerStream) error {
+				req := dynamicpb.NewMessage(subscribeRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+
+				s.apps <- req.Get(subscribeRequest.Fields().ByName("app")).String()
+
+				data := s.current
+
+				for {
+					resp, err := testTree(data)
+					if err != nil {
+						return err
+					}
+
+					if err = stream.SendMsg(resp); err != nil {
+						return err
+					}
+
+					select {
+					case <-stream.Context().Done():
+						return nil
+					case data = <-s.updates:
+					}
+				}
+			},
+		}},
+	}
+}
+
+func testTree(data map[string]any) (*dynamicpb.Message, error) {
+	tree, err := structpb.NewStruct(data)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := proto.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := dynamicpb.NewMessage(configTree)
+	fd := configTree.Fields().ByName("tree")
+
+	if err = proto.Unmarshal(b, resp.Mutable(fd).Message().Interface()); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func TestSource(t *testing.T) {
+	type spec struct {
+		Level string `default:"info"`
+		Port  int
+	}
+
+	origArgs := os.Args
+	defer func() { os.Args = origArgs }()
+
+	os.Clearenv()
+	os.Args = []string{"app"}
+
+	srv := &testServer{
+		current: map[string]any{"port": 8080},
+		updates: make(chan map[string]any),
+		apps:    make(chan string, 2),
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	server.RegisterService(srv.desc(), nil)
+
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	changed := make(chan []structconfig.Change, 1)
+
+	var s spec
+	cfg := structconfig.NewStructConfig(&structconfig.Options{
+		Sources:  []structconfig.Source{New(conn, "myapp")},
+		OnChange: func(changes []structconfig.Change) { changed <- changes },
+	})
+	if _, err = cfg.Process("", &s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s.Port != 8080 {
+		t.Errorf("expected port from Fetch, got %d", s.Port)
+	}
+	if app := <-srv.apps; app != "myapp" {
+		t.Errorf("expected Fetch for myapp, got %q", app)
+	}
+	if _, origin, _ := cfg.Get("port"); origin.String() != "source (grpc)" {
+		t.Errorf("expected source attribution, got %v", origin)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cfg.Watch(ctx)
+
+	if app := <-srv.apps; app != "myapp" {
+		t.Errorf("expected Subscribe for myapp, got %q", app)
+	}
+
+	srv.updates <- map[string]any{"port": 9090, "level": "debug"}
+
+	select {
+	case changes := <-changed:
+		if len(changes) != 2 {
+			t.Errorf("unexpected changes: %+v", changes)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for OnChange")
+	}
+
+	if s.Port != 9090 || s.Level != "debug" {
+		t.Errorf("expected streamed values, got %+v", s)
+	}
+}
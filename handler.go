@@ -0,0 +1,122 @@
+package structconfig
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// configReport is the JSON document served by Handler.
+type configReport struct {
+	ConfigFile string         `json:"config_file,omitempty"`
+	Config     map[string]any `json:"config"`
+	Sources    []keySource    `json:"sources"`
+}
+
+// Handler returns an http.Handler serving the effective configuration as JSON,
+// with secret fields redacted and the source of every key included. It is
+// meant to be mounted on an internal endpoint such as /debug/config.
+func (s *StructConfig) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		report, err := s.report()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+	})
+}
+
+// report builds a redacted snapshot of the effective configuration.
+func (s *StructConfig) report() (configReport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.processed() {
+		return configReport{}, ErrNotProcessed
+	}
+
+	return configReport{
+		ConfigFile: s.configFile,
+		Config:     s.expandKeys(s.redacted(s.merged)),
+		Sources:    s.redactedSourceAttribution(),
+	}, nil
+}
+
+// processed reports whether the merged values and the source attribution
+// match the fields gathered by the last Process call. A Process call that
+// fails before merging leaves them stale.
+func (s *StructConfig) processed() bool {
+	return s.merged != nil && len(s.attribution) == len(s.infos)
+}
+
+// redactedSourceAttribution returns the source attribution with the values of
+// secret fields replaced, or nil when no attribution was built for the
+// current fields.
+func (s *StructConfig) redactedSourceAttribution() []keySource {
+	if len(s.attribution) != len(s.infos) {
+		return nil
+	}
+
+	sources := slices.Clone(s.attribution)
+
+	for i, info := range s.infos {
+		if info.Secret && sources[i].origin.Kind != OriginUnset {
+			sources[i].Value = redactedValue
+		}
+	}
+
+	return sources
+}
+
+var (
+	expvarMu      sync.Mutex
+	expvarTargets = map[string]*atomic.Pointer[StructConfig]{}
+)
+
+// publishExpvar publishes the redacted effective configuration under name.
+// Publishing again under the same name switches the variable to s.
+func (s *StructConfig) publishExpvar(name string) error {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	if target, ok := expvarTargets[name]; ok {
+		target.Store(s)
+		return nil
+	}
+
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("expvar %q is already published", name)
+	}
+
+	target := &atomic.Pointer[StructConfig]{}
+	target.Store(s)
+	expvarTargets[name] = target
+
+	expvar.Publish(name, expvar.Func(func() any {
+		report, err := target.Load().report()
+		if err != nil {
+			return err.Error()
+		}
+
+		return report
+	}))
+
+	return nil
+}
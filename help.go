@@ -0,0 +1,227 @@
+package structconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// helpText renders the usage screen: a settings table with the flag, env var,
+// config key, default, and description of every field, followed by the
+// built-in flags.
+func (s *StructConfig) helpText() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Usage of %s:\n", filepath.Base(os.Args[0]))
+
+	if len(s.infos) > 0 {
+		fmt.Fprintf(&b, "\nSettings:\n%s", s.settingsTable())
+	}
+
+	if builtIn := s.builtInFlagUsages(); builtIn != "" {
+		fmt.Fprintf(&b, "\nOptions:\n%s", builtIn)
+	}
+
+	return b.String()
+}
+
+// settingsTable renders one row per field with its flag type, sorted by flag
+// name. Fields without a flag are listed last, sorted by key. With
+// Options.PreserveFieldOrder the fields keep their declaration order.
+func (s *StructConfig) settingsTable() string {
+	infos := slices.Clone(s.infos)
+	if !s.options.PreserveFieldOrder {
+		sortForHelp(infos)
+	}
+
+	rows := make([][]string, 0, len(infos))
+
+	for _, info := range infos {
+		var flag, short, typ, env string
+
+		if f := helpFlag(info); f != "" {
+			flag = "--" + f
+			if s.negationFlag(info) != nil {
+				flag = "--[" + negationFlagPrefix + "]" + f
+			}
+
+			if value, ok := info.tag.Lookup(tagNoOpt); ok {
+				flag += "[=" + value + "]"
+			}
+
+			if info.ShortFlag != "" && info.ShortFlag != skipTagValue {
+				short = "-" + info.ShortFlag
+			}
+
+			typ = s.typeName(info)
+		}
+
+		if info.Env != skipTagValue {
+			env = info.Env
+		}
+
+		def := info.Default
+		if info.Secret && def != "" {
+			def = redactedValue
+		}
+
+		desc := info.Description
+		if info.Required {
+			desc = strings.TrimSpace(desc + " (required)")
+		}
+
+		if msg := info.tag.Get(tagFlagDeprecated); msg != "" && flag != "" {
+			desc = strings.TrimSpace(desc + " (deprecated: " + msg + ")")
+		}
+
+		rows = append(rows, []string{flag, short, typ, env, info.Key, def, desc})
+	}
+
+	return formatTable([]string{"FLAG", "SHORT", "TYPE", "ENV", "KEY", "DEFAULT", "DESCRIPTION"}, rows)
+}
+
+func sortForHelp(infos []varInfo) {
+	slices.SortStableFunc(infos, func(a, b varInfo) int {
+		fa, fb := helpFlag(a), helpFlag(b)
+		if (fa == "") != (fb == "") {
+			if fa == "" {
+				return 1
+			}
+
+			return -1
+		}
+
+		if c := strings.Compare(fa, fb); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Key, b.Key)
+	})
+}
+
+func helpFlag(info varInfo) string {
+	if info.Flag == skipTagValue {
+		return ""
+	}
+
+	return info.Flag
+}
+
+// builtInFlagUsages returns pflag's usage lines for the flags that are not
+// bound to a field.
+func (s *StructConfig) builtInFlagUsages() string {
+	fieldFlags := make(map[string]bool, len(s.infos))
+	for _, info := range s.infos {
+		fieldFlags[info.Flag] = true
+
+		if neg := s.negationFlag(info); neg != nil {
+			fieldFlags[neg.Name] = true
+		}
+	}
+
+	builtIn := pflag.NewFlagSet("", pflag.ContinueOnError)
+	builtIn.SortFlags = s.flags.SortFlags
+	s.flags.VisitAll(func(f *pflag.Flag) {
+		if !fieldFlags[f.Name] {
+			builtIn.AddFlag(f)
+		}
+	})
+
+	return builtIn.FlagUsages()
+}
+
+// formatTable renders rows as fixed-width columns under a header line and a
+// dashed separator.
+func formatTable(headers []string, rows [][]string) string {
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = len(h)
+	}
+
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	var b strings.Builder
+
+	writeRow := func(cells []string) {
+		for i, cell := range cells {
+			if i > 0 {
+				b.WriteString("  ")
+			}
+
+			fmt.Fprintf(&b, "%-*s", widths[i], cell)
+		}
+
+		b.WriteByte('\n')
+	}
+
+	seps := make([]string, len(widths))
+	for i, w := range widths {
+		seps[i] = strings.Repeat("-", w)
+	}
+
+	writeRow(headers)
+	writeRow(seps)
+
+	for _, row := range rows {
+		writeRow(row)
+	}
+
+	return b.String()
+}
+
+// processPrintEnvFlag lists every environment variable bound to a field.
+func (s *StructConfig) processPrintEnvFlag() (string, error) {
+	if s.options.FlagNames.PrintEnv == skipBuiltInFlagValue {
+		return "", nil
+	}
+
+	printEnv, err := s.flags.GetBool(s.options.FlagNames.PrintEnv)
+	if err != nil || !printEnv {
+		return "", err
+	}
+
+	return s.envTable(), ErrPrintEnvCalled
+}
+
+// envTable renders one row per bound env var, sorted by name.
+func (s *StructConfig) envTable() string {
+	var rows [][]string
+
+	for _, info := range s.infos {
+		if info.Env == "" || info.Env == skipTagValue {
+			continue
+		}
+
+		def := info.Default
+		if info.Secret && def != "" {
+			def = redactedValue
+		}
+
+		rows = append(rows, []string{info.Env, s.typeName(info), def, strconv.FormatBool(info.Required)})
+	}
+
+	slices.SortFunc(rows, func(a, b []string) int { return strings.Compare(a[0], b[0]) })
+
+	return formatTable([]string{"ENV", "TYPE", "DEFAULT", "REQUIRED"}, rows)
+}
+
+// typeName returns the flag type name of a field, or its Go type when the
+// field has no flag.
+func (s *StructConfig) typeName(info varInfo) string {
+	if f := helpFlag(info); f != "" {
+		if pf := s.flags.Lookup(f); pf != nil {
+			return pf.Value.Type()
+		}
+	}
+
+	return info.typ.String()
+}
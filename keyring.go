@@ -0,0 +1,34 @@
+package structconfig
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// KeyringSecretProvider resolves "keyring:service/account" references from
+// the OS credential store: the macOS Keychain, the Secret Service (GNOME
+// Keyring, KWallet) through secret-tool, and the Windows Credential Manager.
+// Entries are looked up under the same names go-keyring stores them, so
+// secrets saved by such tools can be referenced directly. It is not
+// registered by default; register it with
+// RegisterSecretProvider("keyring", KeyringSecretProvider{}).
+type KeyringSecretProvider struct{}
+
+// Resolve reads the password stored for ref, split at its last slash into
+// service and account.
+func (KeyringSecretProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	i := strings.LastIndexByte(ref, '/')
+	if i <= 0 || i == len(ref)-1 {
+		return "", fmt.Errorf("bad keyring reference %q: want service/account", ref)
+	}
+
+	service, account := ref[:i], ref[i+1:]
+
+	secret, err := readKeyring(ctx, service, account)
+	if err != nil {
+		return "", fmt.Errorf("keyring %s/%s: %w", service, account, err)
+	}
+
+	return secret, nil
+}
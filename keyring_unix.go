@@ -0,0 +1,48 @@
+//go:build !windows
+
+package structconfig
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyringCommand returns the command printing the password stored for
+// service and account on goos.
+func keyringCommand(goos, service, account string) []string {
+	if goos == "darwin" {
+		return []string{"security", "find-generic-password", "-s", service, "-a", account, "-w"}
+	}
+
+	return []string{"secret-tool", "lookup", "service", service, "username", account}
+}
+
+func readKeyring(ctx context.Context, service, account string) (string, error) {
+	args := keyringCommand(runtime.GOOS, service, account)
+
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if msg := strings.TrimSpace(stderr.String()); err != nil && msg != "" {
+		return "", errors.New(msg)
+	}
+
+	// secret-tool reports a missing entry by exiting without output.
+	var exitErr *exec.ExitError
+	if len(out) == 0 && (err == nil || errors.As(err, &exitErr)) {
+		return "", errors.New("no such entry")
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
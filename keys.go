@@ -0,0 +1,77 @@
+package structconfig
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// KeyInfo is a canonical config key and the kind of value a config file
+// must hold for it.
+type KeyInfo struct {
+	Key string
+	// Type is one of string, bool, int, uint, float, duration, list<T>,
+	// map<T> with T one of the former, or object for raw sections. Types
+	// parsed from text, such as net.IP, are string.
+	Type string
+}
+
+// Keys returns every canonical config key bound to a field, sorted, with its
+// expected type, so external tools such as admission webhooks and config
+// linters can check files against what a binary accepts. Like Fields, it
+// returns nil until Process has inspected the spec.
+func (s *StructConfig) Keys() []KeyInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.spec == nil {
+		return nil
+	}
+
+	keys := make([]KeyInfo, 0, len(s.infos))
+	for _, info := range s.infos {
+		typ := keyType(info.typ)
+		if info.raw {
+			typ = "object"
+		}
+
+		keys = append(keys, KeyInfo{Key: info.Key, Type: typ})
+	}
+
+	slices.SortFunc(keys, func(a, b KeyInfo) int { return strings.Compare(a.Key, b.Key) })
+
+	return keys
+}
+
+// keyType names the config file value expected for a field of type typ.
+func keyType(typ reflect.Type) string {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch {
+	case typ == durationType:
+		return "duration"
+	case typ == fileModeType || isScalarType(typ):
+		return "string"
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "uint"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Slice, reflect.Array:
+		return "list<" + keyType(typ.Elem()) + ">"
+	case reflect.Map:
+		return "map<" + keyType(typ.Elem()) + ">"
+	case reflect.Struct, reflect.Interface:
+		return "object"
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,132 @@
+package structconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Problem is a defect in a specification reported by Lint.
+type Problem struct {
+	// Key is the config key of the offending field, empty for problems that
+	// concern the whole spec.
+	Key     string
+	Message string
+}
+
+// String formats the problem for display.
+func (p Problem) String() string {
+	if p.Key == "" {
+		return p.Message
+	}
+
+	return p.Key + ": " + p.Message
+}
+
+// Lint checks spec for definitions that would fail or behave surprisingly at
+// runtime: bad tag values, unsupported field types, flags or shorthands that
+// collide with each other or with built-in flags, env vars bound to several
+// fields, and defaults that do not parse into their field type. It reads
+// neither the command line nor the environment and leaves spec untouched, so
+// it can run in a unit test to fail CI on a broken spec.
+func Lint(prefix string, spec any, opts *Options) []Problem {
+	if err := NewStructConfig(opts).checkTags(spec); err != nil {
+		var problems []Problem
+		for _, e := range newProcessError("", err).Errs {
+			problems = append(problems, Problem{Message: e.Error()})
+		}
+
+		return problems
+	}
+
+	s, err := inspect(prefix, spec, opts)
+	if err != nil {
+		return []Problem{{Message: err.Error()}}
+	}
+
+	var problems []Problem
+
+	for i := range s.infos {
+		if err = s.addFlag(&s.infos[i]); err != nil {
+			problems = append(problems, Problem{Key: s.infos[i].Key, Message: err.Error()})
+		}
+	}
+
+	if err = s.addBuiltInFlags(); err != nil {
+		problems = append(problems, Problem{Message: err.Error()})
+	}
+
+	for _, dup := range duplicateEnvs(s.infos) {
+		problems = append(problems, Problem{
+			Key:     dup.keys[0],
+			Message: fmt.Sprintf("env var %s is also bound to %s", dup.env, strings.Join(dup.keys[1:], ", ")),
+		})
+	}
+
+	for _, info := range s.infos {
+		if err = s.checkDefault(info); err != nil {
+			problems = append(problems, Problem{Key: info.Key, Message: err.Error()})
+		}
+	}
+
+	return problems
+}
+
+// inspect gathers the field metadata of spec like Process does, without
+// registering flags or reading any source. spec itself is left untouched.
+func inspect(prefix string, spec any, opts *Options) (*StructConfig, error) {
+	v := reflect.ValueOf(spec)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil, ErrInvalidSpecification
+	}
+
+	s := NewStructConfig(opts)
+	s.prefix = prefix
+
+	infos, err := s.gatherInfo("", prefix, "", nil, reflect.New(v.Elem().Type()).Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.applyEnvAliases(infos); err != nil {
+		return nil, err
+	}
+
+	s.infos = infos
+
+	return s, nil
+}
+
+// envDuplicate is an env var bound to more than one field.
+type envDuplicate struct {
+	env  string
+	keys []string
+}
+
+// duplicateEnvs returns the env vars bound to more than one field, with the
+// keys of those fields in field order.
+func duplicateEnvs(infos []varInfo) []envDuplicate {
+	byEnv := map[string][]string{}
+
+	var order []string
+
+	for _, info := range infos {
+		for _, env := range info.envNames() {
+			if _, ok := byEnv[env]; !ok {
+				order = append(order, env)
+			}
+
+			byEnv[env] = append(byEnv[env], info.Key)
+		}
+	}
+
+	var dups []envDuplicate
+
+	for _, env := range order {
+		if len(byEnv[env]) > 1 {
+			dups = append(dups, envDuplicate{env: env, keys: byEnv[env]})
+		}
+	}
+
+	return dups
+}
@@ -0,0 +1,102 @@
+package structconfig
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// markerTags are the tags a `_ struct{}` marker field may set for the fields
+// declared next to it.
+var markerTags = []string{tagSplitWords, tagSecret, tagRequired, tagAllowEmpty, tagReload, tagEnvNoPrefix}
+
+// markerTag returns the tags of the marker fields named _ in typ, whose
+// values the other fields of typ use unless they set the tag themselves.
+func (s *StructConfig) markerTag(typ reflect.Type) (reflect.StructTag, error) {
+	var tags []string
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if field.Name != "_" {
+			continue
+		}
+
+		tag, err := s.expandCompositeTag(field)
+		if err != nil {
+			return "", err
+		}
+
+		for _, key := range structTagKeys(tag) {
+			if key == s.options.Tags.CompositeTag {
+				continue
+			}
+
+			if !slices.Contains(markerTags, key) {
+				return "", fmt.Errorf("%s tag on marker field of %s: want one of %s", key, typ, strings.Join(markerTags, ", "))
+			}
+
+			val, _ := tag.Lookup(key)
+			tags = append(tags, key+":"+strconv.Quote(val))
+		}
+	}
+
+	return reflect.StructTag(strings.Join(tags, " ")), nil
+}
+
+// withMarkerTag adds the tags of marker that tag does not set to tag.
+func withMarkerTag(tag, marker reflect.StructTag) reflect.StructTag {
+	out := string(tag)
+
+	for _, key := range structTagKeys(marker) {
+		if _, ok := tag.Lookup(key); ok {
+			continue
+		}
+
+		val, _ := marker.Lookup(key)
+		out = strings.TrimSpace(out + " " + key + ":" + strconv.Quote(val))
+	}
+
+	return reflect.StructTag(out)
+}
+
+// structTagKeys returns the keys of tag in order, parsing it the way
+// reflect.StructTag.Lookup does.
+func structTagKeys(tag reflect.StructTag) []string {
+	var keys []string
+
+	for tag != "" {
+		tag = reflect.StructTag(strings.TrimLeft(string(tag), " "))
+
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+
+		name := string(tag[:i])
+		tag = tag[i+1:]
+
+		// Skip the quoted value.
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+
+		if i >= len(tag) {
+			break
+		}
+
+		keys = append(keys, name)
+		tag = tag[i+1:]
+	}
+
+	return keys
+}
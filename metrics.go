@@ -0,0 +1,148 @@
+package structconfig
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const configHashLabel = "config_hash"
+
+// ConfigHash returns a SHA-256 hex digest of the effective configuration,
+// suitable for detecting config drift across a fleet. Values are hashed as
+// decoded into their fields, so 8080 from a file and "8080" from an env var
+// hash alike. Secret fields are left out unless Options.ConfigHashKey is set,
+// since the digest is published as a metric label and could be used to
+// guess weak secrets offline.
+func (s *StructConfig) ConfigHash() (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.configHash()
+}
+
+func (s *StructConfig) configHash() (string, error) {
+	if s.merged == nil {
+		return "", ErrNotProcessed
+	}
+
+	// The merged values are decoded into a fresh spec rather than read from
+	// the processed one, which callers may modify.
+	fresh := reflect.New(reflect.TypeOf(s.spec).Elem())
+	if err := s.unmarshalInto(s.merged, fresh.Interface()); err != nil {
+		return "", err
+	}
+
+	s.normalizeFields(fresh.Elem())
+
+	values := make(map[string]string, len(s.infos))
+
+	for _, info := range s.infos {
+		if s.isAbsent(info.Key) || info.Secret && s.options.ConfigHashKey == nil {
+			continue
+		}
+
+		val := formatValue(fieldByIndex(fresh.Elem(), info.index).Interface())
+
+		if info.Secret {
+			mac := hmac.New(sha256.New, s.options.ConfigHashKey)
+			mac.Write([]byte(val))
+			val = hex.EncodeToString(mac.Sum(nil))
+		}
+
+		values[info.Key] = val
+	}
+
+	// encoding/json sorts map keys, which makes the digest deterministic.
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// InfoLabels returns Prometheus label pairs for an info-style metric: one
+// label per requested key holding its effective value, plus config_hash.
+// Label names are the keys with every character outside [a-zA-Z0-9_] replaced
+// by an underscore. Secret keys cannot be used as labels.
+//
+// The result can be passed as ConstLabels to a client_golang gauge set to 1.
+func (s *StructConfig) InfoLabels(keys ...string) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hash, err := s.configHash()
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{configHashLabel: hash}
+
+	for _, key := range keys {
+		idx := slices.IndexFunc(s.infos, func(info varInfo) bool { return info.Key == key })
+		if idx < 0 {
+			return nil, fmt.Errorf("unknown config key %q", key)
+		}
+
+		info := s.infos[idx]
+		if info.Secret {
+			return nil, fmt.Errorf("config key %q is secret and cannot be used as a label", key)
+		}
+
+		raw, ok := s.merged[key]
+
+		val, err := s.decodeMergedValue(raw, ok, info)
+		if err != nil {
+			return nil, err
+		}
+
+		labels[labelName(key)] = formatValue(val)
+	}
+
+	return labels, nil
+}
+
+// WriteInfoMetric writes an info-style gauge named name in the Prometheus text
+// exposition format, labelled as described by InfoLabels.
+func (s *StructConfig) WriteInfoMetric(w io.Writer, name string, keys ...string) error {
+	labels, err := s.InfoLabels(keys...)
+	if err != nil {
+		return err
+	}
+
+	names := slices.Sorted(maps.Keys(labels))
+
+	pairs := make([]string, 0, len(names))
+	for _, k := range names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, k, labelValueEscaper.Replace(labels[k])))
+	}
+
+	_, err = fmt.Fprintf(w, "# HELP %s Effective configuration info.\n# TYPE %s gauge\n%s{%s} 1\n",
+		name, name, name, strings.Join(pairs, ","))
+
+	return err
+}
+
+func labelName(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+}
+
+// labelValueEscaper escapes label values as required by the text exposition format.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,74 @@
+package structconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// normalizers are the steps accepted by the normalize tag.
+var normalizers = map[string]func(string) string{
+	"trim":  strings.TrimSpace,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// parseNormalize parses a normalize tag such as "trim,lower" into its steps.
+// The tag is accepted on string, *string, and []string fields.
+func parseNormalize(field reflect.StructField, tag string) ([]func(string) string, error) {
+	typ := field.Type
+	if typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() != reflect.String {
+		return nil, fmt.Errorf("%s tag on field %s requires a string or string slice field, got %s", tagNormalize, field.Name, field.Type)
+	}
+
+	var steps []func(string) string
+
+	for _, name := range strings.Split(tag, ",") {
+		step, ok := normalizers[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("bad %s tag value %q for field %s: want trim, lower, or upper", tagNormalize, name, field.Name)
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// normalizeFields applies the normalize tag steps to the decoded values.
+func (s *StructConfig) normalizeFields(spec reflect.Value) {
+	for _, info := range s.infos {
+		if len(info.normalize) == 0 {
+			continue
+		}
+
+		v := fieldByIndex(spec, info.index)
+		s.audit(AuditHook, info.Key, "normalized with %s", info.tag.Get(tagNormalize))
+
+		switch v.Kind() {
+		case reflect.Pointer:
+			if !v.IsNil() {
+				normalizeString(v.Elem(), info.normalize)
+			}
+		case reflect.Slice:
+			for i := range v.Len() {
+				normalizeString(v.Index(i), info.normalize)
+			}
+		default:
+			normalizeString(v, info.normalize)
+		}
+	}
+}
+
+func normalizeString(v reflect.Value, steps []func(string) string) {
+	str := v.String()
+	for _, step := range steps {
+		str = step(str)
+	}
+
+	v.SetString(str)
+}
@@ -0,0 +1,149 @@
+package structconfig
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// optionalSection is a struct pointer field tagged optional:"true".
+type optionalSection struct {
+	key   string
+	index []int
+}
+
+// checkOptionalTag checks that an optional tag is on a struct pointer field.
+func checkOptionalTag(field reflect.StructField, tag string) (bool, error) {
+	optional, err := isTrue2(tag)
+	if err != nil {
+		return false, fmt.Errorf("bad %s tag value for field %s: %w", tagOptional, field.Name, err)
+	}
+
+	if optional && (field.Type.Kind() != reflect.Pointer || field.Type.Elem().Kind() != reflect.Struct || isScalarType(field.Type)) {
+		return false, fmt.Errorf("%s tag on field %s requires a struct pointer field, got %s", tagOptional, field.Name, field.Type)
+	}
+
+	return optional, nil
+}
+
+// dropAbsentSections removes the values of optional sections that no layer
+// above the struct tag defaults mentions, so their defaults do not create
+// configuration for a disabled subsystem. The outermost absent sections are
+// kept in s.absent for clearAbsentSections.
+func (s *StructConfig) dropAbsentSections(m map[string]any) {
+	s.absent = nil
+
+	for _, info := range s.infos {
+		for _, sec := range info.sections {
+			if s.isAbsent(sec.key) || s.sectionPresent(sec) {
+				continue
+			}
+
+			s.absent = append(s.absent, sec)
+
+			for key := range m {
+				if key == sec.key || strings.HasPrefix(key, sec.key+s.options.KeyDelimiter) {
+					delete(m, key)
+				}
+			}
+
+			s.audit(AuditHook, sec.key, "dropped absent optional section")
+		}
+	}
+}
+
+// isAbsent reports whether key is in, or below, a section in s.absent.
+func (s *StructConfig) isAbsent(key string) bool {
+	return slices.ContainsFunc(s.absent, func(sec optionalSection) bool {
+		return key == sec.key || strings.HasPrefix(key, sec.key+s.options.KeyDelimiter)
+	})
+}
+
+// sectionPresent reports whether the default config, secrets dir, config
+// file, a source, an env var, or a flag sets the section or a key below it.
+// The URL env var of a url_components struct in the section counts as well.
+// Feature flags only switch fields of a section and do not count.
+func (s *StructConfig) sectionPresent(sec optionalSection) bool {
+	below := func(key string) bool {
+		return key == sec.key || strings.HasPrefix(key, sec.key+s.options.KeyDelimiter)
+	}
+
+	layers := append([]map[string]any{s.baseData, s.fileData}, s.sourceData...)
+	for _, data := range layers {
+		if s.hasPath(data, sec.key) {
+			return true
+		}
+	}
+
+	for key := range s.secretFiles {
+		if below(key) {
+			return true
+		}
+	}
+
+	for _, info := range s.infos {
+		if !below(info.Key) {
+			continue
+		}
+
+		if info.Env != "" && info.Env != skipTagValue {
+			if _, _, ok := lookupEnv(info); ok {
+				return true
+			}
+		}
+
+		if info.urlSource != nil {
+			if _, _, ok := lookupEnv(*info.urlSource); ok {
+				return true
+			}
+		}
+
+		if info.Flag != "" && info.Flag != skipTagValue && s.flags.Changed(info.Flag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasPath reports whether the nested layer data holds key, even as an empty
+// table such as [cache].
+func (s *StructConfig) hasPath(data map[string]any, key string) bool {
+	parts := strings.Split(key, s.options.KeyDelimiter)
+
+	for i, part := range parts {
+		var next any
+
+		found := false
+
+		for k, v := range data {
+			if s.foldKey(k) == part {
+				next, found = v, true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+
+		nested, ok := next.(map[string]any)
+		if !ok {
+			return i == len(parts)-1
+		}
+
+		data = nested
+	}
+
+	return true
+}
+
+// clearAbsentSections sets the fields of the absent optional sections in v
+// back to nil.
+func (s *StructConfig) clearAbsentSections(v reflect.Value) {
+	for _, sec := range s.absent {
+		f := fieldByIndex(v, sec.index)
+		f.Set(reflect.Zero(f.Type()))
+	}
+}
@@ -0,0 +1,68 @@
+package structconfig
+
+import (
+	"reflect"
+	"slices"
+)
+
+// OriginKind identifies the layer that provided an effective value.
+type OriginKind string
+
+// Origin kinds, from lowest to highest precedence.
+const (
+	OriginUnset   OriginKind = "unset"
+	OriginDefault OriginKind = "default"
+	// OriginDefaultConfig is Options.DefaultConfig.
+	OriginDefaultConfig OriginKind = "default-config"
+	// OriginSecretsDir is a file in Options.SecretsDir.
+	OriginSecretsDir OriginKind = "secrets-dir"
+	OriginFile       OriginKind = "file"
+	OriginSource     OriginKind = "source"
+	// OriginFeature is Options.FeatureProvider; Name is the flag name.
+	OriginFeature OriginKind = "feature"
+	OriginEnv     OriginKind = "env"
+	OriginFlag    OriginKind = "flag"
+)
+
+// Origin describes where an effective value came from.
+type Origin struct {
+	Kind OriginKind
+	// Name identifies the secret file, custom source, feature flag, env
+	// var, or flag ("--port"). It is empty for defaults and the config file;
+	// see ConfigFileUsed for the latter.
+	Name string
+}
+
+// String formats the origin as shown by --debug, e.g. "env (APP_PORT)".
+func (o Origin) String() string {
+	if o.Name == "" {
+		return string(o.Kind)
+	}
+
+	return string(o.Kind) + " (" + o.Name + ")"
+}
+
+// Get returns the effective value of the field bound to key, as stored in the
+// processed spec, and where it came from. Keys match case-insensitively unless
+// Options.CaseSensitiveKeys is set. ok is false when no field has that key,
+// the key is in an optional section no source mentions, or Process has not
+// completed. Secret values are returned as is.
+func (s *StructConfig) Get(key string) (value any, origin Origin, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.processed() {
+		return nil, Origin{}, false
+	}
+
+	key = s.foldKey(key)
+
+	idx := slices.IndexFunc(s.infos, func(info varInfo) bool { return info.Key == key })
+	if idx < 0 || s.isAbsent(key) {
+		return nil, Origin{}, false
+	}
+
+	value = fieldByIndex(reflect.ValueOf(s.spec).Elem(), s.infos[idx].index).Interface()
+
+	return value, s.attribution[idx].origin, true
+}
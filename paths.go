@@ -0,0 +1,118 @@
+package structconfig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// pathCheck is the parsed form of a must_exist tag such as "file,readable".
+type pathCheck struct {
+	dir      bool
+	readable bool
+}
+
+func parsePathCheck(field reflect.StructField, tag string) (*pathCheck, error) {
+	if indirectKind(field.Type) != reflect.String {
+		return nil, fmt.Errorf("%s tag on field %s requires a string field, got %s", tagMustExist, field.Name, field.Type)
+	}
+
+	kind, opts, _ := strings.Cut(tag, ",")
+
+	var check pathCheck
+
+	switch kind {
+	case "file":
+	case "dir":
+		check.dir = true
+	default:
+		return nil, fmt.Errorf("bad %s tag value %q for field %s: want \"file\" or \"dir\"", tagMustExist, tag, field.Name)
+	}
+
+	switch opts {
+	case "":
+	case "readable":
+		check.readable = true
+	default:
+		return nil, fmt.Errorf("bad %s tag option %q for field %s: want \"readable\"", tagMustExist, opts, field.Name)
+	}
+
+	return &check, nil
+}
+
+// checkPaths verifies the decoded values of fields with a must_exist tag.
+// Empty values, and fields of a struct whose gate is off, are not checked;
+// use required to demand a path.
+func (s *StructConfig) checkPaths(spec reflect.Value, merged map[string]any) error {
+	for _, info := range s.infos {
+		if info.mustExist == nil || s.disabled(info, merged) {
+			continue
+		}
+
+		v := fieldByIndex(spec, info.index)
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				continue
+			}
+
+			v = v.Elem()
+		}
+
+		path := v.String()
+		if path == "" {
+			continue
+		}
+
+		if err := info.mustExist.check(path); err != nil {
+			return fmt.Errorf("field %s(%s): %w", info.Name, info.Key, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *pathCheck) check(path string) error {
+	want := "file"
+	if c.dir {
+		want = "directory"
+	}
+
+	fi, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s %q does not exist", want, path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("check %s %q: %w", want, path, err)
+	}
+
+	if c.dir && !fi.IsDir() {
+		return fmt.Errorf("%q is not a directory", path)
+	}
+
+	if !c.dir && fi.IsDir() {
+		return fmt.Errorf("%q is a directory, expected a file", path)
+	}
+
+	if !c.readable {
+		return nil
+	}
+
+	if c.dir {
+		_, err = os.ReadDir(path)
+	} else {
+		var f *os.File
+		if f, err = os.Open(path); err == nil {
+			err = f.Close()
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s %q is not readable: %w", want, path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package structconfig
+
+import (
+	"context"
+	"math/rand/v2"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPollJitter     = 0.1
+	defaultPollMaxBackoff = 8
+)
+
+// PollingSource adds interval-based change detection to a Source without a
+// native watch mechanism. It implements Watcher by re-loading the wrapped
+// source every Interval and notifying when the loaded data differs from the
+// previous load.
+type PollingSource struct {
+	// Source is the wrapped source.
+	Source Source
+	// Interval is the time between polls.
+	Interval time.Duration
+	// Jitter randomizes each wait by up to this fraction of Interval.
+	// It defaults to 0.1.
+	Jitter float64
+	// MaxBackoff caps the wait after consecutive load errors, which doubles
+	// from Interval. It defaults to 8 times Interval.
+	MaxBackoff time.Duration
+	// OnError is called with load errors encountered while polling.
+	OnError func(err error)
+
+	mu         sync.Mutex
+	last       map[string]any
+	pending    map[string]any
+	hasPending bool
+}
+
+// Poll wraps src in a PollingSource that polls every interval.
+func Poll(src Source, interval time.Duration) *PollingSource {
+	return &PollingSource{Source: src, Interval: interval}
+}
+
+// Load returns the data fetched by the poll that triggered a reload, or loads
+// the wrapped source directly.
+func (p *PollingSource) Load(ctx context.Context) (map[string]any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.hasPending {
+		data := p.pending
+		p.pending, p.hasPending = nil, false
+
+		return data, nil
+	}
+
+	data, err := p.Source.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.last = data
+
+	return data, nil
+}
+
+// Watch polls the wrapped source until ctx is done.
+func (p *PollingSource) Watch(ctx context.Context, notify func()) error {
+	failures := 0
+
+	for {
+		timer := time.NewTimer(p.nextWait(failures))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		data, err := p.Source.Load(ctx)
+		if err != nil {
+			failures++
+
+			if p.OnError != nil {
+				p.OnError(err)
+			}
+
+			continue
+		}
+
+		failures = 0
+
+		p.mu.Lock()
+		changed := !reflect.DeepEqual(p.last, data)
+		if changed {
+			p.last = data
+			p.pending, p.hasPending = data, true
+		}
+		p.mu.Unlock()
+
+		if changed {
+			notify()
+		}
+	}
+}
+
+// String names the wrapped source in source attribution output.
+func (p *PollingSource) String() string {
+	return sourceName(p.Source)
+}
+
+// nextWait returns the jittered wait before the next poll, doubling the
+// interval for each consecutive failure up to MaxBackoff.
+func (p *PollingSource) nextWait(failures int) time.Duration {
+	wait := p.Interval
+
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultPollMaxBackoff * p.Interval
+	}
+
+	for range failures {
+		if wait >= maxBackoff/2 {
+			wait = maxBackoff
+			break
+		}
+
+		wait *= 2
+	}
+
+	jitter := p.Jitter
+	if jitter <= 0 {
+		jitter = defaultPollJitter
+	}
+
+	if spread := time.Duration(float64(wait) * jitter); spread > 0 {
+		wait += time.Duration(rand.Int64N(int64(2*spread))) - spread
+	}
+
+	return wait
+}
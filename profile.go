@@ -0,0 +1,68 @@
+package structconfig
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// reservedProfiles are the profile names whose default_<profile> tag is
+// another default tag.
+var reservedProfiles = []string{
+	strings.TrimPrefix(tagDefaultFunc, tagDefault+"_"),
+	strings.TrimPrefix(tagDefaultJSON, tagDefault+"_"),
+	strings.TrimPrefix(tagDefaultInline, tagDefault+"_"),
+}
+
+// isProfileTag reports whether name is a default_<profile> tag, as opposed
+// to default_func, default_json, or default_inline.
+func isProfileTag(name string) bool {
+	profile, ok := strings.CutPrefix(name, tagDefault+"_")
+
+	return ok && profile != "" && !slices.Contains(reservedProfiles, profile)
+}
+
+// activeProfile returns the profile selected by the profile flag, the
+// <PREFIX>_PROFILE env var, or Options.Profile, in that order.
+func (s *StructConfig) activeProfile() (string, error) {
+	if name := s.options.FlagNames.Profile; name != "" && name != skipBuiltInFlagValue {
+		// The flag defaults to Options.Profile.
+		return s.builtInFlagOrEnv(name, envProfileSuffix)
+	}
+
+	if s.prefix != "" {
+		if profile, ok := os.LookupEnv(strings.ToUpper(s.prefix + "_" + envProfileSuffix)); ok {
+			return profile, nil
+		}
+	}
+
+	return s.options.Profile, nil
+}
+
+// applyProfileDefaults replaces field defaults with their default_<profile>
+// tag values for the active profile. Values pre-populated in the spec are
+// kept, and the tag values were already checked by checkDefaults.
+func (s *StructConfig) applyProfileDefaults() error {
+	profile, err := s.activeProfile()
+	if err != nil || profile == "" {
+		return err
+	}
+
+	if !isProfileTag(tagDefault + "_" + profile) {
+		return fmt.Errorf("profile %q is reserved: %s_%s is not a profile tag", profile, tagDefault, profile)
+	}
+
+	for i := range s.infos {
+		if s.infos[i].prefilled {
+			continue
+		}
+
+		if def, ok := s.infos[i].tag.Lookup(tagDefault + "_" + profile); ok {
+			s.infos[i].Default = def
+			s.infos[i].defaultValue = nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package structconfig
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RegistrySource is a Source reading a Windows registry key, such as one
+// managed by Group Policy, into the config tree. Values become config keys
+// and subkeys become nested sections, so SOFTWARE\Policies\MyApp\DB with the
+// value Host sets db.host. String, DWORD, QWORD, and multi-string values are
+// read; others are skipped. A missing key loads as empty. The source is
+// opt-in and only works on Windows; Load fails on other systems.
+type RegistrySource struct {
+	// Root is the hive: "HKLM" (HKEY_LOCAL_MACHINE) or "HKCU"
+	// (HKEY_CURRENT_USER).
+	Root string
+	// Path is the key below Root, e.g. `SOFTWARE\Policies\MyCompany\MyApp`.
+	Path string
+}
+
+// Load reads the key and its subkeys.
+func (r RegistrySource) Load(context.Context) (map[string]any, error) {
+	switch strings.ToUpper(r.Root) {
+	case "HKLM", "HKEY_LOCAL_MACHINE":
+		return loadRegistry(true, r.Path)
+	case "HKCU", "HKEY_CURRENT_USER":
+		return loadRegistry(false, r.Path)
+	default:
+		return nil, fmt.Errorf("bad registry root %q: want HKLM or HKCU", r.Root)
+	}
+}
+
+// String returns the key path for source attribution.
+func (r RegistrySource) String() string {
+	return r.Root + `\` + r.Path
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package structconfig
+
+import "errors"
+
+func loadRegistry(bool, string) (map[string]any, error) {
+	return nil, errors.New("the registry is only available on Windows")
+}
@@ -0,0 +1,157 @@
+package structconfig
+
+import (
+	"encoding/binary"
+	"errors"
+	"syscall"
+	"unsafe"
+)
+
+var procRegEnumValue = advapi32.NewProc("RegEnumValueW")
+
+// loadRegistry reads path below HKEY_LOCAL_MACHINE, or HKEY_CURRENT_USER
+// when machine is false.
+func loadRegistry(machine bool, path string) (map[string]any, error) {
+	root := syscall.Handle(syscall.HKEY_CURRENT_USER)
+	if machine {
+		root = syscall.HKEY_LOCAL_MACHINE
+	}
+
+	key, err := openRegistryKey(root, path)
+	if errors.Is(err, syscall.ERROR_FILE_NOT_FOUND) {
+		return map[string]any{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.RegCloseKey(key)
+
+	return readRegistryKey(key)
+}
+
+func openRegistryKey(parent syscall.Handle, path string) (syscall.Handle, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var key syscall.Handle
+	if err = syscall.RegOpenKeyEx(parent, p, 0, syscall.KEY_READ, &key); err != nil {
+		return 0, err
+	}
+
+	return key, nil
+}
+
+// readRegistryKey returns the values of key and, as nested maps, its
+// subkeys.
+func readRegistryKey(key syscall.Handle) (map[string]any, error) {
+	var subkeys, maxSubkeyLen, values, maxValueNameLen, maxValueLen uint32
+
+	err := syscall.RegQueryInfoKey(key, nil, nil, nil, &subkeys, &maxSubkeyLen, nil, &values, &maxValueNameLen, &maxValueLen, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]any, subkeys+values)
+	name := make([]uint16, maxValueNameLen+1)
+	data := make([]byte, maxValueLen+1)
+
+	for i := range values {
+		nameLen := uint32(len(name))
+		dataLen := uint32(len(data))
+
+		var typ uint32
+
+		r, _, _ := procRegEnumValue.Call(uintptr(key), uintptr(i),
+			uintptr(unsafe.Pointer(&name[0])), uintptr(unsafe.Pointer(&nameLen)), 0,
+			uintptr(unsafe.Pointer(&typ)), uintptr(unsafe.Pointer(&data[0])), uintptr(unsafe.Pointer(&dataLen)))
+		if r != 0 {
+			return nil, syscall.Errno(r)
+		}
+
+		valueName := syscall.UTF16ToString(name[:nameLen])
+		if v, ok := registryValue(typ, data[:dataLen]); ok && valueName != "" {
+			out[valueName] = v
+		}
+	}
+
+	subName := make([]uint16, maxSubkeyLen+1)
+
+	for i := range subkeys {
+		nameLen := uint32(len(subName))
+		if err = syscall.RegEnumKeyEx(key, i, &subName[0], &nameLen, nil, nil, nil, nil); err != nil {
+			return nil, err
+		}
+
+		keyName := syscall.UTF16ToString(subName[:nameLen])
+
+		sub, err := openRegistryKey(key, keyName)
+		if err != nil {
+			return nil, err
+		}
+
+		m, err := readRegistryKey(sub)
+		syscall.RegCloseKey(sub)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out[keyName] = m
+	}
+
+	return out, nil
+}
+
+// registryValue converts registry data of type typ to a config value.
+func registryValue(typ uint32, data []byte) (any, bool) {
+	switch typ {
+	case syscall.REG_SZ, syscall.REG_EXPAND_SZ:
+		return syscall.UTF16ToString(utf16Words(data)), true
+	case syscall.REG_DWORD:
+		if len(data) < 4 {
+			return nil, false
+		}
+
+		return int64(binary.LittleEndian.Uint32(data)), true
+	case syscall.REG_QWORD:
+		if len(data) < 8 {
+			return nil, false
+		}
+
+		return int64(binary.LittleEndian.Uint64(data)), true
+	case syscall.REG_MULTI_SZ:
+		var items []any
+
+		u := utf16Words(data)
+		for len(u) > 0 && u[0] != 0 {
+			end := 0
+			for end < len(u) && u[end] != 0 {
+				end++
+			}
+
+			items = append(items, syscall.UTF16ToString(u[:end]))
+
+			if end < len(u) {
+				end++
+			}
+
+			u = u[end:]
+		}
+
+		return items, true
+	default:
+		return nil, false
+	}
+}
+
+func utf16Words(data []byte) []uint16 {
+	u := make([]uint16, len(data)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(data[2*i:])
+	}
+
+	return u
+}
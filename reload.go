@@ -0,0 +1,77 @@
+package structconfig
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	reloadStatic  = "static"
+	reloadDynamic = "dynamic"
+)
+
+// ErrRestartRequired is returned by Reload and Merge when a field tagged
+// reload:"static" changed. The field keeps its value until the next Process.
+var ErrRestartRequired = errors.New("restart required")
+
+// parseReloadTag parses a reload tag into whether the field is static.
+func parseReloadTag(field reflect.StructField, tag string) (bool, error) {
+	switch tag {
+	case reloadStatic:
+		return true, nil
+	case reloadDynamic:
+		return false, nil
+	default:
+		return false, fmt.Errorf("bad %s tag value %q for field %s: want %s or %s", tagReload, tag, field.Name, reloadStatic, reloadDynamic)
+	}
+}
+
+// isStaticKey reports whether key belongs to a field tagged reload:"static",
+// or to a field nested in one.
+func (s *StructConfig) isStaticKey(key string) bool {
+	for _, info := range s.infos {
+		if info.static && (key == info.Key || strings.HasPrefix(key, info.Key+s.options.KeyDelimiter)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// holdStaticChanges removes the changes to static fields from changes and
+// restores their previous values in merged, so the spec keeps them. It
+// returns the remaining changes and the keys that were held back.
+func (s *StructConfig) holdStaticChanges(changes []Change, merged map[string]any) ([]Change, []string) {
+	var (
+		dynamic []Change
+		held    []string
+	)
+
+	for _, c := range changes {
+		if !s.isStaticKey(c.Key) {
+			dynamic = append(dynamic, c)
+			continue
+		}
+
+		held = append(held, c.Key)
+
+		// Nested keys, such as the entries of a map field, are restored
+		// along with the key itself.
+		prefix := c.Key + s.options.KeyDelimiter
+		for k := range merged {
+			if k == c.Key || strings.HasPrefix(k, prefix) {
+				delete(merged, k)
+			}
+		}
+
+		for k, v := range s.merged {
+			if k == c.Key || strings.HasPrefix(k, prefix) {
+				merged[k] = v
+			}
+		}
+	}
+
+	return dynamic, held
+}
@@ -0,0 +1,208 @@
+package structconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// SecretProvider resolves a secret reference to its value. The reference is
+// the part of a value following the provider's "scheme:" prefix.
+type SecretProvider interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// SecretProviderFunc adapts a function to the SecretProvider interface.
+type SecretProviderFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve calls f(ctx, ref).
+func (f SecretProviderFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+var (
+	secretProvidersMu sync.RWMutex
+	secretProviders   = map[string]SecretProvider{
+		"file": FileSecretProvider{},
+	}
+)
+
+// RegisterSecretProvider registers p for values of the form "scheme:ref".
+// Registering a provider for an existing scheme replaces it; a nil provider
+// removes the scheme.
+func RegisterSecretProvider(scheme string, p SecretProvider) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+
+	if p == nil {
+		delete(secretProviders, scheme)
+		return
+	}
+
+	secretProviders[scheme] = p
+}
+
+func lookupSecretProvider(value string) (SecretProvider, string, bool) {
+	scheme, ref, ok := strings.Cut(value, ":")
+	if !ok || scheme == "" {
+		return nil, "", false
+	}
+
+	secretProvidersMu.RLock()
+	p, ok := secretProviders[scheme]
+	secretProvidersMu.RUnlock()
+
+	return p, ref, ok
+}
+
+// FileSecretProvider resolves "file:/path/to/secret" references by reading the
+// file and trimming a trailing newline. World-readable files are reported
+// through Options.OnWarning.
+type FileSecretProvider struct{}
+
+// Resolve reads the secret file at ref.
+func (FileSecretProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	path := expandPath(strings.TrimPrefix(ref, "//"))
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	checkSecretFileMode(ctx, path, fi)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolveSecrets replaces secret references among the merged values of
+// fields tagged secret in place. Values from custom sources and feature
+// flags are left as they are, so a remote store cannot make the process
+// read local files or credential stores.
+func (s *StructConfig) resolveSecrets(m map[string]any, attribution []keySource) error {
+	for i, info := range s.infos {
+		if !info.Secret {
+			continue
+		}
+
+		if kind := attribution[i].origin.Kind; kind == OriginSource || kind == OriginFeature {
+			continue
+		}
+
+		for k, v := range m {
+			if k != info.Key && !strings.HasPrefix(k, info.Key+s.options.KeyDelimiter) {
+				continue
+			}
+
+			out, err := s.resolveSecretValue(k, v)
+			if err != nil {
+				return fmt.Errorf("resolve secret for key %q: %w", k, err)
+			}
+
+			m[k] = out
+		}
+	}
+
+	return nil
+}
+
+func (s *StructConfig) resolveSecretValue(key string, v any) (any, error) {
+	switch val := v.(type) {
+	case string:
+		p, ref, ok := lookupSecretProvider(val)
+		if !ok {
+			return val, nil
+		}
+
+		s.audit(AuditSecret, key, "resolved through the %s provider", val[:len(val)-len(ref)-1])
+
+		return p.Resolve(s.providerContext(), ref)
+	case []any:
+		out := make([]any, len(val))
+
+		for i, item := range val {
+			resolved, err := s.resolveSecretValue(key, item)
+			if err != nil {
+				return nil, err
+			}
+
+			out[i] = resolved
+		}
+
+		return out, nil
+	case []string:
+		out := make([]string, len(val))
+
+		for i, item := range val {
+			resolved, err := s.resolveSecretValue(key, item)
+			if err != nil {
+				return nil, err
+			}
+
+			out[i] = resolved.(string)
+		}
+
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// secretFile is a value read from Options.SecretsDir.
+type secretFile struct {
+	path  string
+	value string
+}
+
+// loadSecretsDir reads the file named after each field's lowercased env var
+// from Options.SecretsDir. Missing files are skipped.
+func (s *StructConfig) loadSecretsDir() error {
+	s.secretFiles = nil
+
+	if s.options.SecretsDir == "" {
+		return nil
+	}
+
+	dir := expandPath(s.options.SecretsDir)
+	files := make(map[string]secretFile)
+
+	for _, info := range s.infos {
+		if info.Env == "" || info.Env == skipTagValue {
+			continue
+		}
+
+		path := filepath.Join(dir, strings.ToLower(info.Env))
+
+		fi, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) || err == nil && fi.IsDir() {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		checkSecretFileMode(s.providerContext(), path, fi)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		files[info.Key] = secretFile{path: path, value: strings.TrimRight(string(data), "\r\n")}
+		s.audit(AuditSecret, info.Key, "read %s", path)
+	}
+
+	s.secretFiles = files
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package structconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// section is a config struct registered by RegisterSection.
+type section struct {
+	name string
+	spec any
+}
+
+// RegisterSection adds spec, a struct pointer, as the section name of the
+// configuration processed by Finalize. Its fields are keyed below name in
+// config files (db.host), prefixed with it in env vars and flags (DB_HOST,
+// --db-host), and decoded into spec itself. Modules can register their own
+// sections independently; errors such as duplicate names are reported by
+// Finalize.
+func (s *StructConfig) RegisterSection(name string, spec any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sections = append(s.sections, section{name: name, spec: spec})
+}
+
+// Finalize processes the sections registered with RegisterSection as one
+// configuration sharing a flag set, env prefix, and config file. It returns
+// the same output and errors as Process.
+func (s *StructConfig) Finalize(prefix string) (string, error) {
+	s.mu.RLock()
+	spec, err := s.sectionsSpec(prefix)
+	s.mu.RUnlock()
+
+	if err != nil {
+		return "", err
+	}
+
+	return s.Process(prefix, spec)
+}
+
+// sectionsSpec builds a struct with one pointer field per registered
+// section, pointing at the registered spec, for Process to fill in.
+func (s *StructConfig) sectionsSpec(prefix string) (any, error) {
+	if len(s.sections) == 0 {
+		return nil, fmt.Errorf("finalize: no sections registered")
+	}
+
+	fields := make([]reflect.StructField, 0, len(s.sections))
+	names := make(map[string]string, len(s.sections))
+
+	for _, sec := range s.sections {
+		v := reflect.ValueOf(sec.spec)
+		if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return nil, fmt.Errorf("finalize: section %q: %w", sec.name, ErrInvalidSpecification)
+		}
+
+		fieldName := sectionFieldName(sec.name)
+		if fieldName == "" || strings.Contains(sec.name, s.options.KeyDelimiter) {
+			return nil, fmt.Errorf("finalize: bad section name %q", sec.name)
+		}
+
+		if other, ok := names[fieldName]; ok {
+			return nil, fmt.Errorf("finalize: section %q conflicts with section %q", sec.name, other)
+		}
+
+		names[fieldName] = sec.name
+
+		// The env tag keeps a name such as http-server from producing
+		// APP_HTTP-SERVER_ADDR.
+		env := strings.ToUpper(strings.Join(strings.FieldsFunc(sec.name, isNotAlnum), "_"))
+		if prefix != "" {
+			env = strings.ToUpper(prefix) + "_" + env
+		}
+
+		fields = append(fields, reflect.StructField{
+			Name: fieldName,
+			Type: v.Type(),
+			Tag:  reflect.StructTag(fmt.Sprintf(`%s:%q %s:%q`, s.options.Tags.FileTag, sec.name, s.options.Tags.EnvTag, env)),
+		})
+	}
+
+	spec := reflect.New(reflect.StructOf(fields))
+	for i, sec := range s.sections {
+		spec.Elem().Field(i).Set(reflect.ValueOf(sec.spec))
+	}
+
+	return spec.Interface(), nil
+}
+
+// ProcessAll processes several independent specs, each a struct pointer, as
+// one configuration sharing a flag set, env prefix, and config file. Unlike
+// RegisterSection, the fields of every spec stay at the top level, so each
+// spec reads the same keys, env vars, and flags as if processed alone. A key,
+// env var, or flag defined by more than one spec is an error. It returns the
+// same output and errors as Process.
+func (s *StructConfig) ProcessAll(prefix string, specs ...any) (string, error) {
+	s.mu.RLock()
+	spec, err := s.mergedSpec(prefix, specs)
+	s.mu.RUnlock()
+
+	if err != nil {
+		return "", err
+	}
+
+	return s.Process(prefix, spec)
+}
+
+// mergedSpec builds a struct with one squashed pointer field per spec,
+// pointing at the spec, after checking the specs for collisions.
+func (s *StructConfig) mergedSpec(prefix string, specs []any) (any, error) {
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("process: no specs")
+	}
+
+	fields := make([]reflect.StructField, 0, len(specs))
+	owners := make(map[string]int)
+
+	for i, spec := range specs {
+		v := reflect.ValueOf(spec)
+		if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return nil, fmt.Errorf("process: spec %d: %w", i, ErrInvalidSpecification)
+		}
+
+		infos, err := s.gatherInfo("", prefix, "", nil, spec)
+		if err != nil {
+			return nil, fmt.Errorf("process: spec %d: %w", i, err)
+		}
+
+		for _, info := range infos {
+			names := []string{"key " + info.Key}
+			for _, env := range info.envNames() {
+				names = append(names, "env var "+env)
+			}
+
+			if info.Flag != skipTagValue {
+				names = append(names, "flag --"+info.Flag)
+			}
+
+			for _, name := range names {
+				if other, ok := owners[name]; ok && other != i {
+					return nil, fmt.Errorf("process: %s is defined by spec %d and spec %d", name, other, i)
+				}
+
+				owners[name] = i
+			}
+		}
+
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("Spec%d", i),
+			Type: v.Type(),
+			Tag:  reflect.StructTag(fmt.Sprintf(`%s:",squash"`, s.options.Tags.FileTag)),
+		})
+	}
+
+	spec := reflect.New(reflect.StructOf(fields))
+	for i, sp := range specs {
+		spec.Elem().Field(i).Set(reflect.ValueOf(sp))
+	}
+
+	return spec.Interface(), nil
+}
+
+// sectionFieldName turns a section name such as "http-server" into the
+// exported field name HttpServer, or "" when name has no ASCII letters or
+// digits.
+func sectionFieldName(name string) string {
+	var b strings.Builder
+
+	for _, word := range strings.FieldsFunc(name, isNotAlnum) {
+		if b.Len() == 0 && word[0] <= '9' {
+			b.WriteByte('S')
+		}
+
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+
+	return b.String()
+}
+
+func isNotAlnum(r rune) bool {
+	return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+}
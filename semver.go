@@ -0,0 +1,206 @@
+package structconfig
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SemVer is a semantic version such as "1.4.0", "1.4.0-rc.1", or
+// "1.4.0+build.7", following semver 2.0.0. A leading "v" is accepted when
+// parsing and dropped.
+//
+// SemVer implements pflag.Value, so SemVer fields get a flag of type "semver"
+// that rejects invalid versions while flags are parsed.
+type SemVer struct {
+	Major, Minor, Patch uint64
+	// Prerelease holds the dot-separated identifiers after "-", e.g. "rc.1".
+	Prerelease string
+	// Build holds the build metadata after "+", which Compare ignores.
+	Build string
+}
+
+// ParseSemVer parses a semantic version.
+func ParseSemVer(s string) (SemVer, error) {
+	var v SemVer
+
+	rest, build, hasBuild := strings.Cut(strings.TrimPrefix(s, "v"), "+")
+	core, pre, hasPre := strings.Cut(rest, "-")
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return SemVer{}, fmt.Errorf("invalid version %q: want MAJOR.MINOR.PATCH", s)
+	}
+
+	for i, dst := range []*uint64{&v.Major, &v.Minor, &v.Patch} {
+		n, err := parseSemVerNumber(parts[i])
+		if err != nil {
+			return SemVer{}, fmt.Errorf("invalid version %q: %w", s, err)
+		}
+
+		*dst = n
+	}
+
+	if hasPre {
+		if err := checkSemVerIdents(pre, true); err != nil {
+			return SemVer{}, fmt.Errorf("invalid version %q: prerelease: %w", s, err)
+		}
+
+		v.Prerelease = pre
+	}
+
+	if hasBuild {
+		if err := checkSemVerIdents(build, false); err != nil {
+			return SemVer{}, fmt.Errorf("invalid version %q: build: %w", s, err)
+		}
+
+		v.Build = build
+	}
+
+	return v, nil
+}
+
+func parseSemVerNumber(s string) (uint64, error) {
+	if len(s) > 1 && s[0] == '0' {
+		return 0, fmt.Errorf("number %q has a leading zero", s)
+	}
+
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+
+	return n, nil
+}
+
+// checkSemVerIdents validates dot-separated identifiers. Numeric prerelease
+// identifiers must not have leading zeros.
+func checkSemVerIdents(s string, prerelease bool) error {
+	for _, ident := range strings.Split(s, ".") {
+		if ident == "" {
+			return fmt.Errorf("empty identifier in %q", s)
+		}
+
+		numeric := true
+
+		for _, r := range ident {
+			switch {
+			case r >= '0' && r <= '9':
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-':
+				numeric = false
+			default:
+				return fmt.Errorf("invalid character %q in %q", r, ident)
+			}
+		}
+
+		if prerelease && numeric && len(ident) > 1 && ident[0] == '0' {
+			return fmt.Errorf("number %q has a leading zero", ident)
+		}
+	}
+
+	return nil
+}
+
+// String formats the version without a "v" prefix. The zero SemVer stands
+// for an unset version and formats as "".
+func (v SemVer) String() string {
+	if v == (SemVer{}) {
+		return ""
+	}
+
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+
+	return s
+}
+
+// Compare returns -1, 0, or +1 depending on whether v has lower, equal, or
+// higher precedence than w. A prerelease ranks below its release, and build
+// metadata is ignored.
+func (v SemVer) Compare(w SemVer) int {
+	if c := cmp.Compare(v.Major, w.Major); c != 0 {
+		return c
+	}
+
+	if c := cmp.Compare(v.Minor, w.Minor); c != 0 {
+		return c
+	}
+
+	if c := cmp.Compare(v.Patch, w.Patch); c != 0 {
+		return c
+	}
+
+	switch {
+	case v.Prerelease == w.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case w.Prerelease == "":
+		return -1
+	}
+
+	a, b := strings.Split(v.Prerelease, "."), strings.Split(w.Prerelease, ".")
+
+	for i := range min(len(a), len(b)) {
+		if c := compareSemVerIdent(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Compare(len(a), len(b))
+}
+
+// compareSemVerIdent orders numeric identifiers numerically and below
+// alphanumeric ones, which are ordered lexically.
+func compareSemVerIdent(a, b string) int {
+	na, errA := strconv.ParseUint(a, 10, 64)
+	nb, errB := strconv.ParseUint(b, 10, 64)
+
+	switch {
+	case errA == nil && errB == nil:
+		return cmp.Compare(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// Set implements pflag.Value. An empty string resets v to the zero SemVer.
+func (v *SemVer) Set(s string) error {
+	if s == "" {
+		*v = SemVer{}
+		return nil
+	}
+
+	parsed, err := ParseSemVer(s)
+	if err != nil {
+		return err
+	}
+
+	*v = parsed
+
+	return nil
+}
+
+// Type implements pflag.Value.
+func (v *SemVer) Type() string { return "semver" }
+
+// MarshalText implements encoding.TextMarshaler.
+func (v SemVer) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *SemVer) UnmarshalText(text []byte) error {
+	return v.Set(string(text))
+}
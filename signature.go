@@ -0,0 +1,65 @@
+package structconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// signatureSuffix is appended to the config file path to find its detached
+// signature.
+const signatureSuffix = ".sig"
+
+// ErrBadSignature is returned when the config file does not match its
+// detached signature under Options.ConfigPublicKey.
+var ErrBadSignature = errors.New("config file signature does not verify")
+
+// verifyConfigSignature checks data, read from path, against the base64
+// signature in path.sig. Ed25519 signatures are over the file itself and
+// ECDSA signatures over its SHA-256 digest, as written by cosign sign-blob.
+func verifyConfigSignature(path string, data, publicKey []byte) error {
+	block, _ := pem.Decode(publicKey)
+	if block == nil {
+		return errors.New("config public key: no PEM block found")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("config public key: %w", err)
+	}
+
+	encoded, err := os.ReadFile(path + signatureSuffix)
+	if err != nil {
+		return fmt.Errorf("read signature: %w", err)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return fmt.Errorf("decode signature %s: %w", path+signatureSuffix, err)
+	}
+
+	var ok bool
+
+	switch pub := pub.(type) {
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(pub, data, sig)
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		ok = ecdsa.VerifyASN1(pub, digest[:], sig)
+	default:
+		return fmt.Errorf("config public key: unsupported key type %T, want Ed25519 or ECDSA", pub)
+	}
+
+	if !ok {
+		return fmt.Errorf("%s: %w", path, ErrBadSignature)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package structconfig
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+var ipType = reflect.TypeFor[net.IP]()
+
+// addSliceFlag registers a flag typed after the element type of a slice
+// field, so bad elements fail flag parsing and --help names the type.
+// Elements without a typed pflag slice use a string slice flag and are
+// decoded like env vars.
+func (s *StructConfig) addSliceFlag(v *varInfo, elem reflect.Type, descr string) {
+	switch {
+	case elem == durationType:
+		s.flags.VarP(new(durationSliceValue), v.Flag, v.ShortFlag, descr)
+	case elem == ipType:
+		s.flags.IPSliceP(v.Flag, v.ShortFlag, []net.IP{}, descr)
+	case elem.PkgPath() != "" || isScalarType(elem):
+		s.flags.StringSliceP(v.Flag, v.ShortFlag, []string{}, descr)
+	default:
+		switch elem.Kind() {
+		case reflect.Bool:
+			s.flags.BoolSliceP(v.Flag, v.ShortFlag, []bool{}, descr)
+		case reflect.Int:
+			s.flags.IntSliceP(v.Flag, v.ShortFlag, []int{}, descr)
+		case reflect.Int32:
+			s.flags.Int32SliceP(v.Flag, v.ShortFlag, []int32{}, descr)
+		case reflect.Int64:
+			s.flags.Int64SliceP(v.Flag, v.ShortFlag, []int64{}, descr)
+		case reflect.Uint:
+			s.flags.UintSliceP(v.Flag, v.ShortFlag, []uint{}, descr)
+		case reflect.Float32:
+			s.flags.Float32SliceP(v.Flag, v.ShortFlag, []float32{}, descr)
+		case reflect.Float64:
+			s.flags.Float64SliceP(v.Flag, v.ShortFlag, []float64{}, descr)
+		default:
+			s.flags.StringSliceP(v.Flag, v.ShortFlag, []string{}, descr)
+		}
+	}
+}
+
+// readSliceFlag reads a flag registered by addSliceFlag.
+func readSliceFlag(flags *pflag.FlagSet, name string) (any, error) {
+	var typ string
+	if f := flags.Lookup(name); f != nil {
+		typ = f.Value.Type()
+	}
+
+	switch typ {
+	case "boolSlice":
+		return flags.GetBoolSlice(name)
+	case "intSlice":
+		return flags.GetIntSlice(name)
+	case "int32Slice":
+		return flags.GetInt32Slice(name)
+	case "int64Slice":
+		return flags.GetInt64Slice(name)
+	case "uintSlice":
+		return flags.GetUintSlice(name)
+	case "float32Slice":
+		return flags.GetFloat32Slice(name)
+	case "float64Slice":
+		return flags.GetFloat64Slice(name)
+	case "durationSlice":
+		return flags.GetDurationSlice(name)
+	case "ipSlice":
+		return flags.GetIPSlice(name)
+	default:
+		return flags.GetStringSlice(name)
+	}
+}
+
+// durationSliceValue is pflag's durationSlice with the units of
+// parseDuration. Like pflag's slices, the first Set replaces the value and
+// later ones append to it.
+type durationSliceValue struct {
+	ds      []time.Duration
+	changed bool
+}
+
+func (v *durationSliceValue) String() string {
+	out := make([]string, len(v.ds))
+	for i, d := range v.ds {
+		out[i] = d.String()
+	}
+
+	return "[" + strings.Join(out, ",") + "]"
+}
+
+func (v *durationSliceValue) Set(s string) error {
+	var ds []time.Duration
+
+	for _, part := range strings.Split(s, ",") {
+		d, err := parseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return err
+		}
+
+		ds = append(ds, d)
+	}
+
+	if v.changed {
+		v.ds = append(v.ds, ds...)
+	} else {
+		v.ds = ds
+		v.changed = true
+	}
+
+	return nil
+}
+
+func (v *durationSliceValue) Type() string { return "durationSlice" }
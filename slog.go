@@ -0,0 +1,56 @@
+package structconfig
+
+import "log/slog"
+
+// LogValue implements slog.LogValuer. It renders the effective configuration
+// as a group of key/value attributes with secret values redacted, or the
+// ErrNotProcessed message when Process has not completed.
+func (s *StructConfig) LogValue() slog.Value {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.processed() {
+		return slog.StringValue(ErrNotProcessed.Error())
+	}
+
+	sources := s.redactedSourceAttribution()
+	attrs := make([]slog.Attr, 0, len(sources))
+
+	for _, ks := range sources {
+		attrs = append(attrs, slog.String(ks.Key, ks.Value))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// LogConfig logs every resolved key with its value and source at info level,
+// one record per key, with secret values redacted. When Process has not
+// completed it logs a single warning instead. The logger is called without
+// holding the config lock, so its handler may call back into s.
+func (s *StructConfig) LogConfig(logger *slog.Logger) {
+	s.mu.RLock()
+	ctx := s.options.Context
+	configFile := s.configFile
+	processed := s.processed()
+	sources := s.redactedSourceAttribution()
+	s.mu.RUnlock()
+
+	if !processed {
+		logger.LogAttrs(ctx, slog.LevelWarn, ErrNotProcessed.Error())
+		return
+	}
+
+	if configFile != "" {
+		logger.LogAttrs(ctx, slog.LevelInfo, "config file loaded", slog.String("path", configFile))
+	}
+
+	for _, ks := range sources {
+		logger.LogAttrs(ctx, slog.LevelInfo, "config value",
+			slog.String("key", ks.Key),
+			slog.String("value", ks.Value),
+			slog.String("source", ks.Source),
+		)
+	}
+}
+
+var _ slog.LogValuer = (*StructConfig)(nil)
@@ -0,0 +1,115 @@
+package structconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// jsonNumberType is decoded by mapstructure as a number, so the hooks that
+// parse strings leave it alone.
+var jsonNumberType = reflect.TypeFor[json.Number]()
+
+// snapshotVersion is the format version written by Snapshot.
+const snapshotVersion = 1
+
+type snapshot struct {
+	Version    int            `json:"version"`
+	ConfigFile string         `json:"config_file,omitempty"`
+	Values     map[string]any `json:"values"`
+	// Absent holds the keys of the optional sections no source mentioned,
+	// which LoadSnapshot leaves nil.
+	Absent []string `json:"absent,omitempty"`
+}
+
+// Snapshot returns the effective configuration resolved by Process as JSON,
+// for LoadSnapshot to replay later without the original file, environment,
+// and flags. Secret values are included as is, so the snapshot must be
+// stored like the secrets themselves.
+func (s *StructConfig) Snapshot() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.merged == nil {
+		return nil, ErrNotProcessed
+	}
+
+	absent := make([]string, 0, len(s.absent))
+	for _, sec := range s.absent {
+		absent = append(absent, sec.key)
+	}
+
+	data, err := json.MarshalIndent(snapshot{
+		Version:    snapshotVersion,
+		ConfigFile: s.configFile,
+		Values:     s.expandKeys(s.merged),
+		Absent:     absent,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: %w", err)
+	}
+
+	return data, nil
+}
+
+// LoadSnapshot fills spec from a snapshot written by Snapshot, decoding the
+// values, applying the normalize and template tags, and leaving absent
+// optional sections nil as Process does. Path checks are skipped, since a
+// snapshot is often replayed on another host. The spec must use the default
+// struct tag names.
+func LoadSnapshot(data []byte, spec any) error {
+	var snap snapshot
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if err := dec.Decode(&snap); err != nil {
+		return fmt.Errorf("load snapshot: %w", err)
+	}
+
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("load snapshot: unsupported version %d", snap.Version)
+	}
+
+	s := NewStructConfig(nil)
+
+	infos, err := s.gatherInfo("", "", "", nil, spec)
+	if err != nil {
+		return fmt.Errorf("load snapshot: %w", err)
+	}
+
+	s.infos = infos
+
+	decoder, err := s.newDecoder(spec)
+	if err != nil {
+		return err
+	}
+
+	if err = decoder.Decode(snap.Values); err != nil {
+		return fmt.Errorf("load snapshot: %w", err)
+	}
+
+	v := reflect.ValueOf(spec).Elem()
+
+	initNilMaps(v)
+
+	s.normalizeFields(v)
+
+	if err = s.renderTemplates(v); err != nil {
+		return err
+	}
+
+	for _, info := range s.infos {
+		for _, sec := range info.sections {
+			if slices.Contains(snap.Absent, sec.key) && !s.isAbsent(sec.key) {
+				s.absent = append(s.absent, sec)
+			}
+		}
+	}
+
+	s.clearAbsentSections(v)
+
+	return nil
+}
@@ -0,0 +1,297 @@
+package structconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ErrNotProcessed is returned by methods that need the state built by Process
+// when Process has not completed successfully.
+var ErrNotProcessed = errors.New("config has not been processed")
+
+// Source provides configuration values from an external system such as a
+// database or an API. Load returns a nested map keyed like a config file.
+type Source interface {
+	Load(ctx context.Context) (map[string]any, error)
+}
+
+// Watcher is implemented by sources that can report changes. Watch blocks
+// until ctx is done or watching fails, calling notify after each change.
+type Watcher interface {
+	Watch(ctx context.Context, notify func()) error
+}
+
+// SourceFunc adapts a function to the Source interface.
+type SourceFunc func(ctx context.Context) (map[string]any, error)
+
+// Load calls f(ctx).
+func (f SourceFunc) Load(ctx context.Context) (map[string]any, error) {
+	return f(ctx)
+}
+
+// sourceName returns the label used for src in source attribution output.
+func sourceName(src Source) string {
+	if st, ok := src.(fmt.Stringer); ok {
+		return st.String()
+	}
+
+	return fmt.Sprintf("%T", src)
+}
+
+func (s *StructConfig) loadSources() error {
+	data := make([]map[string]any, len(s.options.Sources))
+
+	for i, src := range s.options.Sources {
+		m, err := src.Load(s.options.Context)
+		if err != nil {
+			return fmt.Errorf("%s: %w", sourceName(src), err)
+		}
+
+		data[i] = m
+		s.audit(AuditSource, sourceName(src), "loaded %d keys", len(s.flattenMap("", m)))
+	}
+
+	s.sourceData = data
+
+	return nil
+}
+
+// Reload re-reads the config file, secrets dir, sources, and environment
+// variables, keeps the flags parsed by Process, and updates the processed spec
+// in place. It returns the changed keys and passes them to Options.OnChange
+// when non-empty. With Options.WriteConfigOnReload, an error writing the
+// config file is returned together with the changes. Fields tagged
+// reload:"static" keep their value; when one changed, the other changes are
+// applied and an error wrapping ErrRestartRequired names the static keys.
+// Fields are replaced one by one, so callers reading the spec concurrently
+// must synchronize with Reload themselves.
+func (s *StructConfig) Reload() ([]Change, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if s.spec == nil || s.merged == nil {
+		return nil, ErrNotProcessed
+	}
+
+	s.auditLog = nil
+
+	if err := s.readConfigFile(s.configFile); err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	if err := s.decryptValues(s.fileData); err != nil {
+		return nil, fmt.Errorf("decrypt config file: %w", err)
+	}
+
+	if err := s.loadSecretsDir(); err != nil {
+		return nil, fmt.Errorf("load secrets dir: %w", err)
+	}
+
+	if err := s.loadSources(); err != nil {
+		return nil, fmt.Errorf("load sources: %w", err)
+	}
+
+	return s.update()
+}
+
+// Merge loads srcs and layers them over the sources already in use, then
+// updates the processed spec in place like Reload. It suits apps that load a
+// bootstrap config with Process and then an extended config, e.g. from a
+// remote store located through the bootstrap values. The added sources rank
+// above earlier ones and below env vars and flags, and are kept for later
+// Reload and Watch calls.
+func (s *StructConfig) Merge(srcs ...Source) ([]Change, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if s.spec == nil || s.merged == nil {
+		return nil, ErrNotProcessed
+	}
+
+	s.auditLog = nil
+
+	data := make([]map[string]any, len(srcs))
+
+	for i, src := range srcs {
+		m, err := src.Load(s.options.Context)
+		if err != nil {
+			return nil, fmt.Errorf("load sources: %s: %w", sourceName(src), err)
+		}
+
+		data[i] = m
+		s.audit(AuditSource, sourceName(src), "loaded %d keys", len(s.flattenMap("", m)))
+	}
+
+	prevSources, prevData := s.options.Sources, s.sourceData
+
+	s.options.Sources = append(slices.Clip(s.options.Sources), srcs...)
+	s.sourceData = append(slices.Clip(s.sourceData), data...)
+
+	// Errors returned with changes, or for held back static keys, come
+	// after the spec was updated, so the sources are kept.
+	changes, err := s.update()
+	if err != nil && changes == nil && !errors.Is(err, ErrRestartRequired) {
+		s.options.Sources, s.sourceData = prevSources, prevData
+		return nil, err
+	}
+
+	return changes, err
+}
+
+// update rebuilds the merged values from the loaded layers and copies the
+// changed result into the processed spec.
+func (s *StructConfig) update() ([]Change, error) {
+	merged, attribution, err := s.buildMerged()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.checkRequired(merged); err != nil {
+		return nil, err
+	}
+
+	fresh := reflect.New(reflect.TypeOf(s.spec).Elem()).Elem()
+	if err = s.unmarshalInto(merged, fresh.Addr().Interface()); err != nil {
+		return nil, err
+	}
+
+	initNilMaps(fresh)
+
+	s.normalizeFields(fresh)
+
+	if err = s.renderTemplates(fresh); err != nil {
+		return nil, err
+	}
+
+	s.clearAbsentSections(fresh)
+
+	// The changes are those Diff reports between the current spec and the
+	// updated one.
+	dst := reflect.ValueOf(s.spec).Elem()
+	changes, held := s.holdStaticChanges(diffSpecs(s.infos, dst, fresh), merged)
+
+	var restartErr error
+	if len(held) > 0 {
+		restartErr = fmt.Errorf("%w: static keys changed: %s", ErrRestartRequired, strings.Join(held, ", "))
+	}
+
+	if len(changes) == 0 {
+		s.attribution = attribution
+		return nil, restartErr
+	}
+
+	for _, info := range s.infos {
+		if s.isStaticKey(info.Key) {
+			fieldByIndex(fresh, info.index).Set(fieldByIndex(dst, info.index))
+		}
+	}
+
+	if err = s.checkPaths(fresh, merged); err != nil {
+		return nil, err
+	}
+
+	for _, info := range s.infos {
+		fieldByIndex(dst, info.index).Set(fieldByIndex(fresh, info.index))
+	}
+
+	s.clearAbsentSections(dst)
+
+	s.merged = merged
+	s.attribution = attribution
+
+	if onChange := s.options.OnChange; onChange != nil {
+		s.queue(func() { onChange(changes) })
+	}
+
+	// The spec is already updated, so a failed write is returned along
+	// with the changes.
+	if s.options.WriteConfigOnReload {
+		if err = s.processWriteConfigFlag(); err != nil {
+			return changes, err
+		}
+	}
+
+	return changes, restartErr
+}
+
+// Watch starts the watchers of all sources implementing Watcher and reloads
+// the configuration whenever one of them reports a change. With
+// Options.EnvPollInterval, changes to the env vars bound to fields trigger a
+// reload as well. Reload errors are passed to Options.OnReloadError. Watch
+// blocks until ctx is done or a watcher fails.
+func (s *StructConfig) Watch(ctx context.Context) error {
+	s.mu.RLock()
+
+	if s.spec == nil || s.merged == nil {
+		s.mu.RUnlock()
+		return ErrNotProcessed
+	}
+
+	var watchers []Watcher
+
+	for _, src := range s.options.Sources {
+		if w, ok := src.(Watcher); ok {
+			watchers = append(watchers, w)
+		}
+	}
+
+	if s.options.EnvPollInterval > 0 {
+		watchers = append(watchers, envWatcher{s: s, interval: s.options.EnvPollInterval})
+	}
+
+	s.mu.RUnlock()
+
+	return s.runWatchers(ctx, watchers)
+}
+
+func (s *StructConfig) runWatchers(ctx context.Context, watchers []Watcher) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errc := make(chan error, len(watchers))
+
+	for _, w := range watchers {
+		go func() {
+			errc <- w.Watch(ctx, s.notifyChange)
+		}()
+	}
+
+	var firstErr error
+
+	for range watchers {
+		if err := <-errc; err != nil && firstErr == nil && ctx.Err() == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	return firstErr
+}
+
+func (s *StructConfig) notifyChange() {
+	if _, err := s.Reload(); err != nil && s.options.OnReloadError != nil {
+		s.options.OnReloadError(err)
+	}
+}
+
+// fieldByIndex returns the nested field of v at index, allocating nil struct
+// pointers along the way.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(i)
+	}
+
+	return v
+}
@@ -0,0 +1,110 @@
+package structconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// splitEscaped splits s around each occurrence of sep into at most n fields
+// (all fields when n < 0), skipping separators that are escaped or quoted:
+//
+//   - a backslash escapes the character following it;
+//   - a double quote at the start of a field, or directly after one of
+//     delims, opens a quoted section that ends at the next unescaped double
+//     quote.
+//
+// The fields keep their escapes and quotes; unescapeField removes them.
+func splitEscaped(s, sep string, n int, delims ...string) ([]string, error) {
+	var (
+		fields []string
+		start  int
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '"' && opensQuote(s, start, i, delims):
+			end := closingQuote(s, i+1)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote in %q", s)
+			}
+
+			i = end
+		case strings.HasPrefix(s[i:], sep) && (n < 0 || len(fields) < n-1):
+			fields = append(fields, s[start:i])
+			start = i + len(sep)
+			i = start - 1
+		}
+	}
+
+	return append(fields, s[start:]), nil
+}
+
+func opensQuote(s string, fieldStart, i int, delims []string) bool {
+	if i == fieldStart {
+		return true
+	}
+
+	for _, d := range delims {
+		if strings.HasSuffix(s[fieldStart:i], d) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// closingQuote returns the index of the first unescaped double quote in s at
+// or after i, or -1.
+func closingQuote(s string, i int) int {
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+
+	return -1
+}
+
+// unescapeField removes the quotes around a quoted field and resolves the
+// escapes of separators, quotes, and backslashes. Other backslashes are kept,
+// so Windows paths need no escaping.
+func unescapeField(field string, seps ...string) string {
+	if len(field) >= 2 && field[0] == '"' && closingQuote(field, 1) == len(field)-1 {
+		field = field[1 : len(field)-1]
+	}
+
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+1 < len(field) && isEscapable(field[i+1], seps) {
+			i++
+		}
+
+		b.WriteByte(field[i])
+	}
+
+	return b.String()
+}
+
+func isEscapable(c byte, seps []string) bool {
+	if c == '\\' || c == '"' {
+		return true
+	}
+
+	for _, sep := range seps {
+		if strings.HasPrefix(sep, string(c)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+package structconfig
+
+import (
+	"slices"
+	"time"
+)
+
+// Phase names a step of Process timed by Stats.
+type Phase string
+
+// Process phases in the order they run.
+const (
+	// PhaseGather reads the spec's fields and registers their flags.
+	PhaseGather Phase = "gather"
+	PhaseFlags  Phase = "flags"
+	// PhaseFile locates and reads the config file, the default config, and
+	// the secrets dir.
+	PhaseFile    Phase = "file"
+	PhaseSources Phase = "sources"
+	// PhaseMerge merges all layers, resolving secret references and
+	// feature flags.
+	PhaseMerge     Phase = "merge"
+	PhaseValidate  Phase = "validate"
+	PhaseUnmarshal Phase = "unmarshal"
+)
+
+// PhaseTiming is the time spent in one Process phase.
+type PhaseTiming struct {
+	Phase    Phase
+	Duration time.Duration
+}
+
+// Stats returns the time the last Process spent in each phase it reached, in
+// order, to diagnose slow startups such as ones caused by remote sources.
+func (s *StructConfig) Stats() []PhaseTiming {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.stats)
+}
+
+// startPhase ends the running phase and starts timing p, calling
+// Options.OnPhase when set.
+func (s *StructConfig) startPhase(p Phase) {
+	s.endPhase()
+
+	start := time.Now()
+
+	var end func()
+	if s.options.OnPhase != nil {
+		end = s.options.OnPhase(p)
+	}
+
+	s.phaseEnd = func() {
+		s.addPhaseTiming(p, time.Since(start))
+
+		if end != nil {
+			end()
+		}
+	}
+}
+
+// endPhase ends the running phase, if any.
+func (s *StructConfig) endPhase() {
+	if s.phaseEnd != nil {
+		s.phaseEnd()
+		s.phaseEnd = nil
+	}
+}
+
+// addPhaseTiming adds d to the time spent in p, which Process may enter
+// more than once.
+func (s *StructConfig) addPhaseTiming(p Phase, d time.Duration) {
+	for i := range s.stats {
+		if s.stats[i].Phase == p {
+			s.stats[i].Duration += d
+			return
+		}
+	}
+
+	s.stats = append(s.stats, PhaseTiming{Phase: p, Duration: d})
+}
@@ -0,0 +1,61 @@
+package structconfig
+
+import (
+	"context"
+	"sync"
+)
+
+// StreamSource is a Source fed by a config service that pushes updates, such
+// as a gRPC server streaming config trees. It keeps this package free of the
+// transport: Fetch and Subscribe wrap the client calls.
+type StreamSource struct {
+	// Name labels the source in --debug output. It defaults to "stream".
+	Name string
+	// Fetch returns the current config tree, keyed like a config file.
+	Fetch func(ctx context.Context) (map[string]any, error)
+	// Subscribe blocks until ctx is done or the stream fails, passing every
+	// config tree received to update.
+	Subscribe func(ctx context.Context, update func(data map[string]any)) error
+
+	mu     sync.Mutex
+	latest map[string]any
+}
+
+// Load returns the last tree received through Subscribe, or calls Fetch
+// before the first update.
+func (s *StreamSource) Load(ctx context.Context) (map[string]any, error) {
+	s.mu.Lock()
+	latest := s.latest
+	s.mu.Unlock()
+
+	if latest != nil {
+		return latest, nil
+	}
+
+	return s.Fetch(ctx)
+}
+
+// Watch subscribes to updates and notifies after each one, so StructConfig
+// reloads with the received tree.
+func (s *StreamSource) Watch(ctx context.Context, notify func()) error {
+	return s.Subscribe(ctx, func(data map[string]any) {
+		if data == nil {
+			data = map[string]any{}
+		}
+
+		s.mu.Lock()
+		s.latest = data
+		s.mu.Unlock()
+
+		notify()
+	})
+}
+
+// String returns Name for source attribution.
+func (s *StreamSource) String() string {
+	if s.Name == "" {
+		return "stream"
+	}
+
+	return s.Name
+}
@@ -1,15 +1,26 @@
 package structconfig
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"maps"
 	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+	"unicode/utf8"
 
 	"github.com/go-viper/mapstructure/v2"
 	toml "github.com/pelletier/go-toml/v2"
@@ -21,11 +32,21 @@ import (
 // ErrVersionCalled will be returned by Process when the --version flag is set.
 // ErrDefaultConfigCalled will be returned by Process when the --default-config flag is set.
 // ErrDebugCalled will be returned by Process when the --debug flag is set.
+// ErrDiffDefaultsCalled will be returned by Process when the --diff-defaults flag is set.
+// ErrHelpRequested will be returned by Process when -h or --help is set.
+// ErrPrintEnvCalled will be returned by Process when the --print-env flag is set.
+// ErrAuditCalled will be returned by Process when the audit flag is set.
+// ErrConcurrentProcess will be returned by Process when another Process call on the same StructConfig is running.
 var (
 	ErrInvalidSpecification = errors.New("specification must be a struct pointer")
 	ErrVersionCalled        = errors.New("version flag was set")
 	ErrDefaultConfigCalled  = errors.New("default-config flag was set")
 	ErrDebugCalled          = errors.New("debug flag was set")
+	ErrDiffDefaultsCalled   = errors.New("diff-defaults flag was set")
+	ErrHelpRequested        = errors.New("help flag was set")
+	ErrPrintEnvCalled       = errors.New("print-env flag was set")
+	ErrAuditCalled          = errors.New("audit flag was set")
+	ErrConcurrentProcess    = errors.New("process is already running")
 )
 
 var (
@@ -37,47 +58,84 @@ const (
 	skipTagValue         = "-"
 	skipBuiltInFlagValue = "-"
 	defaultConfigType    = "toml"
-
-	tagRequired    = "required"
-	tagEnv         = "env"
-	tagFlag        = "flag"
-	tagShortFlag   = "short"
-	tagFile        = "file"
-	tagDefault     = "default"
-	tagDescription = "desc"
-	tagIgnored     = "ignored"
-	tagSplitWords  = "split_words"
+	defaultConfigName    = "config"
+	defaultKeyDelimiter  = "."
+
+	tagRequired      = "required"
+	tagAllowEmpty    = "allow_empty"
+	tagRequiredMsg   = "required_msg"
+	tagEnv           = "env"
+	tagFlag          = "flag"
+	tagShortFlag     = "short"
+	tagFile          = "file"
+	tagDefault       = "default"
+	tagDescription   = "desc"
+	tagIgnored       = "ignored"
+	tagSplitWords    = "split_words"
+	tagEnvNoPrefix   = "env_noprefix"
+	tagURLComponents = "url_components"
+	tagSecret        = "secret"
+	tagMustExist     = "must_exist"
+	tagExpand        = "expand"
+	tagNormalize     = "normalize"
+	tagTemplate      = "template"
+	tagDurationUnit  = "duration_unit"
+	tagFeature       = "feature"
+	tagReload        = "reload"
+	tagOptional      = "optional"
+	tagGate          = "gate"
+	tagDefaultFunc   = "default_func"
+	tagBuildInfo     = "buildinfo"
+	tagRuntime       = "runtime"
+	tagDefaultJSON   = "default_json"
+	tagDefaultInline = "default_inline"
+
+	tagFlagDeprecated  = "flag_deprecated"
+	tagShortDeprecated = "short_deprecated"
+	tagNoOpt           = "noopt"
+
+	redactedValue = "<redacted>"
+
+	envConfigPathSuffix = "CONFIG"
+	envConfigTypeSuffix = "CONFIG_TYPE"
+	envProfileSuffix    = "PROFILE"
 
 	flagConfigPath    = "config"
 	flagConfigType    = "config-type"
 	flagDefaultConfig = "default-config"
 	flagVersion       = "version"
 	flagDebug         = "debug"
+	flagDebugFull     = "debug-unredacted"
+	flagWriteConfig   = "write-config"
+	flagDiffDefaults  = "diff-defaults"
+	flagPrintEnv      = "print-env"
+
+	negationFlagPrefix = "no-"
+
+	debugKeys   = "keys"
+	debugValues = "values"
+	debugFull   = "full"
 
 	shortConfigPath    = "c"
 	shortConfigType    = "t"
 	shortDefaultConfig = "p"
 	shortVersion       = "V"
 	shortDebug         = "d"
-
-	sourceDefault = "default"
-	sourceFile    = "file"
-	sourceEnv     = "env"
-	sourceFlag    = "flag"
-	sourceUnset   = "unset"
 )
 
 // keySource records the effective value and its origin for a single config key.
 type keySource struct {
-	Key    string
-	Value  string
-	Source string
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	origin Origin
 }
 
 // varInfo maintains information about the configuration variable.
 type varInfo struct {
 	Default     string
 	typ         reflect.Type
+	index       []int
 	Name        string
 	Key         string
 	Env         string
@@ -86,6 +144,40 @@ type varInfo struct {
 	File        string
 	Description string
 	Required    bool
+	AllowEmpty  bool
+	Secret      bool
+	Expand      bool
+	tag         reflect.StructTag
+	// defaultValue, when non-nil, is seeded into the merged map instead of
+	// Default, which then only serves for display. It holds defaults given
+	// as structured data, e.g. by default_json.
+	defaultValue any
+	// prefilled marks a default taken from a value already in the spec; it
+	// outranks the default tags, default_<profile> included.
+	prefilled bool
+	// normalize holds the parsed normalize tag steps in order.
+	normalize []func(string) string
+	// durationUnit is the parsed duration_unit tag; 0 when the tag is absent.
+	durationUnit time.Duration
+	// template is the parsed template tag; nil when the tag is absent.
+	template *template.Template
+	// mustExist is the parsed must_exist tag; nil when the tag is absent.
+	mustExist *pathCheck
+	// static is set by reload:"static"; Reload keeps the field's value.
+	static bool
+	// sections are the enclosing optional sections, outermost first.
+	sections []optionalSection
+	// gates are the keys of the gate fields of the enclosing structs.
+	gates []string
+	// envAliases are the env vars after the first in an env tag such as
+	// env:"NEW_NAME,OLD_NAME", read when Env is not set.
+	envAliases []string
+	// urlSource is the struct tagged url_components whose env var holds a
+	// URL with the value of this field, e.g. its host.
+	urlSource *varInfo
+	// raw marks a RawSection or map[string]any field whose file subtree is
+	// passed through verbatim instead of being flattened.
+	raw bool
 }
 
 // VersionFunc returns the version string used by the built-in version flag.
@@ -99,17 +191,168 @@ var defaultVersionFunc VersionFunc = func() string {
 type StructConfig struct {
 	flags    *pflag.FlagSet
 	options  *Options
+	spec     any
 	fileData map[string]any
-	infos    []varInfo
+	// baseData holds the parsed Options.DefaultConfig.
+	baseData   map[string]any
+	sourceData []map[string]any
+	// featureData holds the values from Options.FeatureProvider by key.
+	featureData map[string]any
+	// absent holds the optional sections that no source mentions.
+	absent []optionalSection
+	// auditLog holds the steps of the last Process, Reload, or Merge.
+	auditLog []AuditEvent
+	// stats holds the phase timings of the last Process for Stats, and
+	// phaseEnd ends the phase being timed.
+	stats    []PhaseTiming
+	phaseEnd func()
+	// secretFiles holds the files found in Options.SecretsDir by key.
+	secretFiles map[string]secretFile
+	merged      map[string]any
+	// attribution holds the origin of each field's value, in infos order,
+	// as recorded by buildMerged.
+	attribution []keySource
+	configFile  string
+	prefix      string
+	infos       []varInfo
+	// pending holds the OnWarning, OnChange, and HelpFunc calls queued while
+	// mu is held for writing, run by unlock once it is released.
+	pending []func()
+	// mu guards the state above. Process, Reload, Merge, and Reset hold it
+	// for writing; the exported readers hold it for reading.
+	mu sync.RWMutex
+	// processing is set while Process runs, to reject concurrent calls.
+	processing atomic.Bool
+	// sections holds the specs added by RegisterSection for Finalize.
+	sections []section
+	// initial holds the options as filled in by NewStructConfig, before
+	// Process and Merge changed them, for Reset and Clone.
+	initial Options
 }
 
 // Options configures StructConfig behavior.
 type Options struct {
 	VersionFunc VersionFunc
 	ConfigType  string
+	// ConfigName is the file name, without extension, looked up in SearchPaths.
+	ConfigName string
+	// Version, when set, provides the structured version info printed by
+	// the version flag instead of VersionFunc, e.g. a VersionInfo filled in
+	// with -ldflags. --version=json prints it as JSON.
+	Version VersionProvider
+	// SearchPaths lists directories searched in order for a config file when
+	// the config path flag is not given. The first existing file wins.
+	SearchPaths []string
 	Tags        OptionTags
 	FlagNames   OptionFlagNames
 	FlagShorts  OptionFlagShorts
+	// Context is passed to providers consulted during Process. It defaults to
+	// context.Background().
+	Context context.Context
+	// KeyProvider supplies the key for inline encrypted values (enc:AES256:...)
+	// found in config files.
+	KeyProvider KeyProvider
+	// Sources are loaded in order after the config file and before environment
+	// variables; later sources override earlier ones.
+	Sources []Source
+	// OnChange is called by Reload with the keys whose values changed. Like
+	// OnWarning and HelpFunc, it runs once Reload has released its lock, so
+	// it may call Get and the other readers.
+	OnChange func(changes []Change)
+	// OnReloadError is called when a reload triggered by Watch fails.
+	OnReloadError func(err error)
+	// FlagErrorHandling selects how flag parse errors are handled. The default
+	// ContinueOnError returns them from Process.
+	FlagErrorHandling pflag.ErrorHandling
+	// HelpFunc, when set, receives the help text on -h/--help instead of it
+	// being returned as Process output.
+	HelpFunc func(help string)
+	// OnWarning is called for non-fatal issues such as unknown config file
+	// keys or world-readable secret files.
+	OnWarning func(w Warning)
+	// ExpvarName, when set, publishes the redacted effective config under this
+	// expvar name after a successful Process.
+	ExpvarName string
+	// ConfigHashKey, when set, includes secret fields in ConfigHash as
+	// HMAC-SHA256 digests under this key, so rotated secrets show up as
+	// drift. Without it secret fields are left out of the hash.
+	ConfigHashKey []byte
+	// Profile is the active profile used to pick default_<profile> tags. It is
+	// overridden by the <PREFIX>_PROFILE env var and by the profile flag when
+	// FlagNames.Profile is set.
+	Profile string
+	// PreserveFieldOrder lists fields in --help in struct declaration order,
+	// keeping the fields of each nested struct together, instead of sorting
+	// them by flag name.
+	PreserveFieldOrder bool
+	// SecretsDir, when set, is searched for one file per field named after
+	// its lowercased env var, e.g. /run/secrets/myapp_db_password, as used by
+	// Docker and Kubernetes secret mounts. File contents override defaults
+	// and are overridden by every other source.
+	SecretsDir string
+	// NegateBoolFlags registers a --no-<flag> counterpart for every bool
+	// field flag, so a feature that defaults to true can be disabled with
+	// --no-feature instead of --feature=false.
+	NegateBoolFlags bool
+	// StrictEnv makes Process fail when an environment variable starts with
+	// the env prefix but does not match any field, instead of reporting it
+	// through OnWarning.
+	StrictEnv bool
+	// CaseSensitiveKeys keeps the case of config file, source, and map keys
+	// instead of lowercasing them, and binds a field with a file tag such as
+	// `file:"listenAddr"` only to that exact key. Fields without a file tag
+	// keep their lowercase key.
+	CaseSensitiveKeys bool
+	// KeyDelimiter separates the segments of nested keys, such as db.host,
+	// in flattened keys and in Get. It defaults to "."; set it to e.g. "::"
+	// when map keys such as host names contain dots.
+	KeyDelimiter string
+	// DefaultConfig is a config file, typically embedded with go:embed,
+	// merged below the config file and above the struct tag defaults, so
+	// the file on disk only needs the settings that differ from it.
+	DefaultConfig []byte
+	// DefaultConfigType is the format of DefaultConfig. It defaults to
+	// ConfigType.
+	DefaultConfigType string
+	// FeatureProvider evaluates the flags named by feature tags on every
+	// Process and Reload. Flag values override custom sources and are
+	// overridden by environment variables and CLI flags.
+	FeatureProvider FeatureProvider
+	// ConfigPublicKey, when set, is a PEM-encoded Ed25519 or ECDSA public key.
+	// The config file must then carry a detached base64 signature in the
+	// same path with a .sig suffix, as written by cosign sign-blob, or
+	// Process and Reload fail before parsing it.
+	ConfigPublicKey []byte
+	// OnPhase, when set, is called as each Process phase starts and returns
+	// a function called as it ends, e.g. to wrap the phases in tracing
+	// spans. See Stats for the timings. Both run while Process holds its
+	// lock and must not call the methods of the StructConfig.
+	OnPhase func(phase Phase) (end func())
+	// WriteConfigOnReload rewrites the file given with --write-config after
+	// every Reload or Merge that changes a value, so the file always shows
+	// the config in use.
+	WriteConfigOnReload bool
+	// EnvPollInterval, when set, makes Watch re-read the env vars bound to
+	// fields at this interval and reload when one of them changed, e.g.
+	// after the process re-read an env file rewritten by an orchestrator,
+	// or in tests.
+	EnvPollInterval time.Duration
+	// SplitWords applies split_words:"true" to every field without a
+	// split_words tag, so AutoSplitVar reads PREFIX_AUTO_SPLIT_VAR.
+	SplitWords bool
+	// Acronyms are the acronyms used by split_words to split runs of
+	// capitals, so HTTPAPIKey reads PREFIX_HTTP_API_KEY with
+	// DefaultAcronyms. nil keeps the runs whole: PREFIX_HTTPAPI_KEY.
+	Acronyms []string
+	// FlagNameFunc, when set, derives the long flag name of a field without
+	// a flag tag from the derived flag name of its enclosing struct, "" at
+	// the top level, and the field's name, its file tag if set. "-" gives
+	// the field no flag.
+	FlagNameFunc func(prefix, name string) string
+	// EnvAliases maps legacy env var names to the config keys they set, e.g.
+	// {"DB_URL": "database.dsn"}, for renames without touching the env tags.
+	// The aliases are read after the field's own env vars.
+	EnvAliases map[string]string
 }
 
 // OptionTags defines struct tag names used for config keys, env vars, and flags.
@@ -119,6 +362,10 @@ type OptionTags struct {
 	ShortTag string
 	EnvTag   string
 	DescTag  string
+	// CompositeTag names a tag holding several tags as options, e.g.
+	// config:"name=db_host,env=DB_HOST,required" with "config". It is
+	// disabled unless set, since other libraries may own the tag name.
+	CompositeTag string
 }
 
 // OptionFlagNames customizes built-in long flag names.
@@ -128,6 +375,17 @@ type OptionFlagNames struct {
 	DefaultConfig string
 	Version       string
 	Debug         string
+	// DebugFull names the flag that must accompany --debug=full.
+	DebugFull    string
+	WriteConfig  string
+	DiffDefaults string
+	PrintEnv     string
+	// Profile names the flag selecting the active profile. Unlike the other
+	// built-in flags it is disabled unless set.
+	Profile string
+	// Audit names the flag printing the audit trail of Process and exiting.
+	// Like Profile it is disabled unless set.
+	Audit string
 }
 
 // OptionFlagShorts customizes built-in short flag aliases.
@@ -137,6 +395,12 @@ type OptionFlagShorts struct {
 	DefaultConfig string
 	Version       string
 	Debug         string
+	DebugFull     string
+	WriteConfig   string
+	DiffDefaults  string
+	PrintEnv      string
+	Profile       string
+	Audit         string
 }
 
 func (o *Options) fillDefaults() *Options {
@@ -144,6 +408,10 @@ func (o *Options) fillDefaults() *Options {
 		o = &Options{}
 	}
 
+	if o.Context == nil {
+		o.Context = context.Background()
+	}
+
 	if o.VersionFunc == nil {
 		o.VersionFunc = defaultVersionFunc
 	}
@@ -152,6 +420,18 @@ func (o *Options) fillDefaults() *Options {
 		o.ConfigType = defaultConfigType
 	}
 
+	if o.DefaultConfigType == "" {
+		o.DefaultConfigType = o.ConfigType
+	}
+
+	if o.KeyDelimiter == "" {
+		o.KeyDelimiter = defaultKeyDelimiter
+	}
+
+	if o.ConfigName == "" {
+		o.ConfigName = defaultConfigName
+	}
+
 	if o.Tags.FileTag == "" {
 		o.Tags.FileTag = tagFile
 	}
@@ -192,6 +472,22 @@ func (o *Options) fillDefaults() *Options {
 		o.FlagNames.Debug = flagDebug
 	}
 
+	if o.FlagNames.DebugFull == "" {
+		o.FlagNames.DebugFull = flagDebugFull
+	}
+
+	if o.FlagNames.WriteConfig == "" {
+		o.FlagNames.WriteConfig = flagWriteConfig
+	}
+
+	if o.FlagNames.DiffDefaults == "" {
+		o.FlagNames.DiffDefaults = flagDiffDefaults
+	}
+
+	if o.FlagNames.PrintEnv == "" {
+		o.FlagNames.PrintEnv = flagPrintEnv
+	}
+
 	if o.FlagShorts.ConfigPath == "" {
 		o.FlagShorts.ConfigPath = shortConfigPath
 	}
@@ -216,7 +512,7 @@ func (o *Options) fillDefaults() *Options {
 }
 
 // gatherInfo gathers information about the specified struct.
-func (s *StructConfig) gatherInfo(prefix, envPrefix string, spec any) ([]varInfo, error) {
+func (s *StructConfig) gatherInfo(prefix, envPrefix, flagPrefix string, index []int, spec any) ([]varInfo, error) {
 	specValue := reflect.ValueOf(spec)
 
 	if specValue.Kind() != reflect.Pointer {
@@ -230,18 +526,42 @@ func (s *StructConfig) gatherInfo(prefix, envPrefix string, spec any) ([]varInfo
 
 	typeOfSpec := specValue.Type()
 
+	// gateKey is the key of the gate field of the struct, if any, and
+	// gateTagged is set when it is tagged gate:"true" rather than named
+	// enabled. A tagged gate outranks an enabled field.
+	var (
+		gateKey    string
+		gateTagged bool
+	)
+
+	marker, err := s.markerTag(typeOfSpec)
+	if err != nil {
+		return nil, err
+	}
+
 	infos := make([]varInfo, 0, specValue.NumField())
 	for i := range specValue.NumField() {
 		f := specValue.Field(i)
 
 		ftype := typeOfSpec.Field(i)
-		if !f.CanSet() || isTrue(ftype.Tag.Get(tagIgnored)) {
+		if !f.CanSet() {
+			continue
+		}
+
+		ftype.Tag, err = s.expandCompositeTag(ftype)
+		if err != nil {
+			return nil, err
+		}
+
+		ftype.Tag = withMarkerTag(ftype.Tag, marker)
+
+		if isTrue(ftype.Tag.Get(tagIgnored)) {
 			continue
 		}
 
 		for f.Kind() == reflect.Pointer {
 			if f.IsNil() {
-				if f.Type().Elem().Kind() != reflect.Struct {
+				if f.Type().Elem().Kind() != reflect.Struct || isScalarType(f.Type()) {
 					break
 				}
 
@@ -256,83 +576,277 @@ func (s *StructConfig) gatherInfo(prefix, envPrefix string, spec any) ([]varInfo
 			return nil, fmt.Errorf("bad required tag value for field %s: %w", ftype.Name, err)
 		}
 
+		def, err := computedDefault(ftype)
+		if err != nil {
+			return nil, err
+		}
+
 		info := varInfo{
 			Name:        ftype.Name,
 			Env:         ftype.Tag.Get(s.options.Tags.EnvTag),
 			Flag:        ftype.Tag.Get(s.options.Tags.FlagTag),
 			File:        ftype.Tag.Get(s.options.Tags.FileTag),
 			ShortFlag:   ftype.Tag.Get(s.options.Tags.ShortTag),
-			Default:     ftype.Tag.Get(tagDefault),
+			Default:     def,
 			Description: ftype.Tag.Get(s.options.Tags.DescTag),
 			Required:    required,
+			Secret:      isTrue(ftype.Tag.Get(tagSecret)),
 			typ:         ftype.Type,
+			tag:         ftype.Tag,
+			index:       append(slices.Clone(index), i),
+		}
+
+		info.Env, info.envAliases = splitEnvTag(info.Env)
+
+		if err = setJSONDefault(&info, ftype); err != nil {
+			return nil, err
+		}
+
+		if err = setInlineDefault(&info, ftype, s.options.ConfigType); err != nil {
+			return nil, err
 		}
 
+		setPrepopulatedDefault(&info, f)
+
 		if info.File != "" {
 			info.Name = info.File
 		}
 
+		if _, ok := ftype.Tag.Lookup(tagRequiredMsg); ok && !required {
+			return nil, fmt.Errorf("%s tag on field %s requires the required tag", tagRequiredMsg, ftype.Name)
+		}
+
+		info.AllowEmpty, err = isTrue2(ftype.Tag.Get(tagAllowEmpty))
+		if err != nil {
+			return nil, fmt.Errorf("bad allow_empty tag value for field %s: %w", ftype.Name, err)
+		}
+
+		info.Expand, err = isTrue2(ftype.Tag.Get(tagExpand))
+		if err != nil {
+			return nil, fmt.Errorf("bad expand tag value for field %s: %w", ftype.Name, err)
+		}
+
+		if info.Expand && indirectKind(ftype.Type) != reflect.String {
+			return nil, fmt.Errorf("expand tag on field %s requires a string field, got %s", ftype.Name, ftype.Type)
+		}
+
+		if tag, ok := ftype.Tag.Lookup(tagNormalize); ok {
+			info.normalize, err = parseNormalize(ftype, tag)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if tag, ok := ftype.Tag.Lookup(tagDurationUnit); ok {
+			info.durationUnit, err = parseDurationUnit(ftype, tag)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if tag, ok := ftype.Tag.Lookup(tagTemplate); ok {
+			info.template, err = parseTemplate(ftype, info, tag)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if tag, ok := ftype.Tag.Lookup(tagReload); ok {
+			info.static, err = parseReloadTag(ftype, tag)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		optional, err := checkOptionalTag(ftype, ftype.Tag.Get(tagOptional))
+		if err != nil {
+			return nil, err
+		}
+
+		gate, gateTag, err := checkGateTag(ftype, info.Name, prefix != "")
+		if err != nil {
+			return nil, err
+		}
+
+		fromURL, err := checkURLComponentsTag(ftype, ftype.Tag.Get(tagURLComponents))
+		if err != nil {
+			return nil, err
+		}
+
+		if tag, ok := ftype.Tag.Lookup(tagFeature); ok {
+			if err = checkFeatureTag(ftype, tag); err != nil {
+				return nil, err
+			}
+		}
+
+		if tag, ok := ftype.Tag.Lookup(tagMustExist); ok {
+			info.mustExist, err = parsePathCheck(ftype, tag)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if isRawSection(ftype.Type) {
+			info.raw = true
+			info.Flag = skipTagValue
+			info.Env = skipTagValue
+		}
+
 		info.Key = info.Name
+		if info.File == "" || !s.options.CaseSensitiveKeys {
+			info.Key = strings.ToLower(info.Key)
+		}
+
+		info.Key = s.keyPath(prefix, info.Key)
+
+		if gate {
+			if gateTag && gateTagged {
+				return nil, fmt.Errorf("%s tag on field %s: %s is already the gate of its struct", tagGate, ftype.Name, gateKey)
+			}
+
+			if gateTag || gateKey == "" {
+				gateKey, gateTagged = info.Key, gateTag
+			}
+		}
+
+		noPrefix, err := isTrue2(ftype.Tag.Get(tagEnvNoPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("bad %s tag value for field %s: %w", tagEnvNoPrefix, ftype.Name, err)
+		}
 
-		if prefix != "" {
-			info.Key = prefix + "." + info.Key
+		if noPrefix && info.Env != "" {
+			return nil, fmt.Errorf("%s tag on field %s requires a generated env var name", tagEnvNoPrefix, ftype.Name)
 		}
 
-		info.Key = strings.ToLower(info.Key)
+		split := s.options.SplitWords
+		if tag, ok := ftype.Tag.Lookup(tagSplitWords); ok {
+			split = isTrue(tag)
+		}
 
 		if info.Env == "" {
-			name := splitWords(info.Name, isTrue(ftype.Tag.Get(tagSplitWords)))
+			name := splitWords(info.Name, split, s.options.Acronyms)
 
 			if envPrefix != "" {
 				info.Env = strings.ToUpper(envPrefix + "_" + name)
+
+				// The bare name is the fallback for platforms that inject
+				// standard vars such as PORT.
+				if noPrefix {
+					info.envAliases = append([]string{strings.ToUpper(name)}, info.envAliases...)
+				}
 			} else {
 				info.Env = strings.ToUpper(name)
 			}
 		}
 
+		// Nested fields derive their flags from the derived flag of the
+		// struct, not from its flag tag.
+		flagName := s.flagName(flagPrefix, info.Name, split)
 		if info.Flag == "" {
-			info.Flag = strings.ReplaceAll(info.Key, ".", "-")
+			info.Flag = flagName
 		}
 
 		infos = append(infos, info)
 
-		if f.Kind() == reflect.Struct {
+		if f.Kind() == reflect.Struct && !isScalarType(f.Type()) {
 			innerPrefix := prefix
 			innerEnvPrefix := envPrefix
+			innerFlagPrefix := flagPrefix
 
-			if !ftype.Anonymous {
+			// Like an embedded struct, a field whose file tag has the
+			// squash option is flattened, matching how it is decoded.
+			if !ftype.Anonymous && !hasSquash(ftype.Tag.Get(s.options.Tags.FileTag)) {
 				innerPrefix = info.Key
 				innerEnvPrefix = info.Env
+				innerFlagPrefix = flagName
 			}
 
 			embeddedPtr := f.Addr().Interface()
 
-			embeddedInfos, err := s.gatherInfo(innerPrefix, innerEnvPrefix, embeddedPtr)
+			embeddedInfos, err := s.gatherInfo(innerPrefix, innerEnvPrefix, innerFlagPrefix, info.index, embeddedPtr)
 			if err != nil {
 				return nil, err
 			}
 
+			if err = s.applyStructDefault(info, innerPrefix, embeddedInfos); err != nil {
+				return nil, err
+			}
+
+			// A struct tagged reload:"static" holds its fields static.
+			if info.static {
+				for j := range embeddedInfos {
+					embeddedInfos[j].static = true
+				}
+			}
+
+			if fromURL {
+				src := info
+				for j := range embeddedInfos {
+					rest := strings.TrimPrefix(embeddedInfos[j].Key, info.Key+s.options.KeyDelimiter)
+					if !strings.Contains(rest, s.options.KeyDelimiter) {
+						embeddedInfos[j].urlSource = &src
+					}
+				}
+			}
+
+			if optional {
+				sec := optionalSection{key: info.Key, index: info.index}
+				for j := range embeddedInfos {
+					embeddedInfos[j].sections = append([]optionalSection{sec}, embeddedInfos[j].sections...)
+				}
+			}
+
 			infos = append(infos[:len(infos)-1], embeddedInfos...)
 
 			continue
 		}
 	}
 
+	if gateKey != "" {
+		for j := range infos {
+			if infos[j].Key != gateKey {
+				infos[j].gates = append(infos[j].gates, gateKey)
+			}
+		}
+	}
+
 	return infos, nil
 }
 
-func splitWords(key string, split bool) string {
+// hasSquash reports whether a file tag such as ",squash" has the squash
+// option.
+func hasSquash(tag string) bool {
+	_, opts, _ := strings.Cut(tag, ",")
+
+	return slices.Contains(strings.Split(opts, ","), "squash")
+}
+
+// splitWords splits a field name into words joined by underscores. Runs of
+// capitals made of acronyms are split into them.
+func splitWords(key string, split bool, acronyms []string) string {
 	if !split {
 		return key
 	}
 
+	// run splits a run of capitals by acronyms, or keeps it whole.
+	run := func(s string) []string {
+		if parts := splitAcronyms(s, acronyms); parts != nil {
+			return parts
+		}
+
+		return []string{s}
+	}
+
 	words := gatherRegexp.FindAllStringSubmatch(key, -1)
 	if len(words) > 0 {
 		var name []string
 
 		for _, words := range words {
 			if m := acronymRegexp.FindStringSubmatch(words[0]); len(m) == 3 {
-				name = append(name, m[1], m[2])
+				name = append(name, run(m[1])...)
+				name = append(name, m[2])
+			} else if words[0] == strings.ToUpper(words[0]) {
+				name = append(name, run(words[0])...)
 			} else {
 				name = append(name, words[0])
 			}
@@ -346,12 +860,74 @@ func splitWords(key string, split bool) string {
 
 // NewStructConfig creates a StructConfig with the provided options.
 //
-// StructConfig is intended to be used once during application startup.
+// StructConfig is intended to be used once during application startup. Call
+// Reset before processing again, or Clone for another spec.
 func NewStructConfig(o *Options) *StructConfig {
-	return &StructConfig{
-		flags:   pflag.NewFlagSet("flag set", pflag.ContinueOnError),
-		options: o.fillDefaults(),
+	o = o.fillDefaults()
+
+	s := &StructConfig{
+		options: o,
+		initial: *o.clone(),
 	}
+
+	s.flags = s.newFlagSet()
+
+	return s
+}
+
+func (s *StructConfig) newFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("flag set", s.options.FlagErrorHandling)
+	flags.Usage = s.usage
+	flags.SortFlags = !s.options.PreserveFieldOrder
+
+	return flags
+}
+
+// clone returns a copy of o that does not share its slices.
+func (o *Options) clone() *Options {
+	c := *o
+	c.SearchPaths = slices.Clone(o.SearchPaths)
+	c.Sources = slices.Clone(o.Sources)
+	c.EnvAliases = maps.Clone(o.EnvAliases)
+	c.Acronyms = slices.Clone(o.Acronyms)
+
+	return &c
+}
+
+// Reset clears the state built by Process, including the registered and
+// parsed flags and the sections added by RegisterSection, so the same
+// instance can process a spec again. Options are restored to their values at
+// construction, dropping changes such as the config type chosen by
+// --config-type and the sources added by Merge.
+// Reset must not be called while Watch is running.
+func (s *StructConfig) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.options = s.initial.clone()
+	s.flags = s.newFlagSet()
+	s.spec = nil
+	s.fileData = nil
+	s.baseData = nil
+	s.sourceData = nil
+	s.featureData = nil
+	s.absent = nil
+	s.auditLog = nil
+	s.stats = nil
+	s.secretFiles = nil
+	s.merged = nil
+	s.attribution = nil
+	s.configFile = ""
+	s.prefix = ""
+	s.infos = nil
+	s.sections = nil
+}
+
+// Clone returns a new StructConfig with the options s was created with and
+// none of its processing state, for processing another spec with the same
+// settings or isolating tests.
+func (s *StructConfig) Clone() *StructConfig {
+	return NewStructConfig(s.initial.clone())
 }
 
 // Process populates the specified struct based on environment, flags, config file,
@@ -363,9 +939,29 @@ func Process(prefix string, spec any) (string, error) {
 // Process populates the specified struct based on environment, flags, config file,
 // and default values. Priority: flags > env vars > config file > struct tag defaults.
 func (s *StructConfig) Process(prefix string, spec any) (string, error) {
+	if !s.processing.CompareAndSwap(false, true) {
+		return "", ErrConcurrentProcess
+	}
+	defer s.processing.Store(false)
+
+	s.mu.Lock()
+	defer s.unlock()
+
 	var err error
 
-	s.infos, err = s.gatherInfo("", prefix, spec)
+	s.prefix = prefix
+	s.spec = spec
+	s.auditLog = nil
+	s.stats = nil
+
+	defer s.endPhase()
+	s.startPhase(PhaseGather)
+
+	if err = s.checkTags(spec); err != nil {
+		return "", fmt.Errorf("gather info: %w", err)
+	}
+
+	s.infos, err = s.gatherInfo("", prefix, "", nil, spec)
 	if err != nil {
 		if errors.Is(err, ErrInvalidSpecification) {
 			return "", ErrInvalidSpecification
@@ -374,6 +970,18 @@ func (s *StructConfig) Process(prefix string, spec any) (string, error) {
 		return "", fmt.Errorf("gather info: %w", err)
 	}
 
+	if err = s.applyEnvAliases(s.infos); err != nil {
+		return "", fmt.Errorf("gather info: %w", err)
+	}
+
+	if err = s.checkDefaults(spec); err != nil {
+		return "", fmt.Errorf("gather info: %w", err)
+	}
+
+	if dups := duplicateEnvs(s.infos); len(dups) > 0 {
+		return "", fmt.Errorf("gather info: env var %s is bound to several fields: %s", dups[0].env, strings.Join(dups[0].keys, ", "))
+	}
+
 	for i := range s.infos {
 		err = s.addFlag(&s.infos[i])
 		if err != nil {
@@ -386,11 +994,34 @@ func (s *StructConfig) Process(prefix string, spec any) (string, error) {
 		return "", fmt.Errorf("add built-in flags: %w", err)
 	}
 
+	s.startPhase(PhaseFlags)
+
 	err = s.flags.Parse(os.Args[1:])
+	if errors.Is(err, pflag.ErrHelp) {
+		return s.processHelp()
+	}
+
 	if err != nil {
 		return "", fmt.Errorf("parse flags: %w", err)
 	}
 
+	s.warnDeprecatedFlags()
+
+	if err = s.applyProfileDefaults(); err != nil {
+		return "", err
+	}
+
+	// The config type is resolved before the control-flow flags so that
+	// --default-config uses the format picked with --config-type.
+	configPath, configType, err := s.getConfigPathAndType()
+	if err != nil {
+		return "", err
+	}
+
+	if configType != "" {
+		s.options.ConfigType = configType
+	}
+
 	versionOut, err := s.processVersionFlag()
 	if err != nil {
 		return versionOut, err
@@ -401,13 +1032,21 @@ func (s *StructConfig) Process(prefix string, spec any) (string, error) {
 		return configOut, err
 	}
 
-	configPath, configType, err := s.getConfigPathAndType()
+	envOut, err := s.processPrintEnvFlag()
 	if err != nil {
-		return "", err
+		return envOut, err
 	}
 
-	if configType != "" {
-		s.options.ConfigType = configType
+	s.startPhase(PhaseFile)
+
+	if configPath == "" {
+		configPath = s.findConfigFile()
+	} else {
+		configPath = expandPath(configPath)
+	}
+
+	if err = s.readDefaultConfig(); err != nil {
+		return "", fmt.Errorf("read default config: %w", err)
 	}
 
 	err = s.readConfigFile(configPath)
@@ -415,49 +1054,160 @@ func (s *StructConfig) Process(prefix string, spec any) (string, error) {
 		return "", fmt.Errorf("read config file: %w", err)
 	}
 
-	merged, err := s.buildMerged()
+	if err = s.decryptValues(s.fileData); err != nil {
+		return "", fmt.Errorf("decrypt config file: %w", err)
+	}
+
+	s.warnUnknownFileKeys()
+
+	if err = s.checkUnknownEnv(); err != nil {
+		return "", err
+	}
+
+	if err = s.loadSecretsDir(); err != nil {
+		return "", fmt.Errorf("load secrets dir: %w", err)
+	}
+
+	s.startPhase(PhaseSources)
+
+	if err = s.loadSources(); err != nil {
+		return "", fmt.Errorf("load sources: %w", err)
+	}
+
+	s.startPhase(PhaseMerge)
+
+	merged, attribution, err := s.buildMerged()
 	if err != nil {
 		return "", err
 	}
 
+	s.attribution = attribution
+
 	debugOut, err := s.processDebugFlag(merged)
 	if err != nil {
 		return debugOut, err
 	}
 
+	auditOut, err := s.processAuditFlag()
+	if err != nil {
+		return auditOut, err
+	}
+
+	diffOut, err := s.processDiffDefaultsFlag(merged)
+	if err != nil {
+		return diffOut, err
+	}
+
+	s.startPhase(PhaseValidate)
+
 	if err = s.checkRequired(merged); err != nil {
 		return "", err
 	}
 
+	s.startPhase(PhaseUnmarshal)
+
 	if err = s.unmarshalInto(merged, spec); err != nil {
 		return "", err
 	}
 
 	initNilMaps(reflect.ValueOf(spec).Elem())
 
+	s.normalizeFields(reflect.ValueOf(spec).Elem())
+
+	if err = s.renderTemplates(reflect.ValueOf(spec).Elem()); err != nil {
+		return "", err
+	}
+
+	s.startPhase(PhaseValidate)
+
+	if err = s.checkPaths(reflect.ValueOf(spec).Elem(), merged); err != nil {
+		return "", err
+	}
+
+	s.clearAbsentSections(reflect.ValueOf(spec).Elem())
+
+	s.endPhase()
+
+	s.merged = merged
+
+	if err = s.processWriteConfigFlag(); err != nil {
+		return "", err
+	}
+
+	if s.options.ExpvarName != "" {
+		if err = s.publishExpvar(s.options.ExpvarName); err != nil {
+			return "", err
+		}
+	}
+
 	return "", nil
 }
 
 // buildMerged assembles a flat dot-keyed map from all sources in priority order:
-// struct tag defaults < config file < environment variables < CLI flags.
-func (s *StructConfig) buildMerged() (map[string]any, error) {
+// struct tag defaults < default config < secrets dir < config file < custom
+// sources < feature flags < environment variables < CLI flags. It also returns
+// the origin of each field's value, read from the same environment.
+func (s *StructConfig) buildMerged() (map[string]any, []keySource, error) {
 	m := make(map[string]any, len(s.infos))
 
 	for _, info := range s.infos {
-		if info.Default != "" {
-			m[info.Key] = info.Default
+		def, ok := info.defaultRaw()
+		if !ok {
+			continue
 		}
+
+		// Map defaults are flattened like file data so that config file
+		// entries merge with them instead of replacing the whole map.
+		if nested, isMap := def.(map[string]any); isMap && !info.raw {
+			maps.Copy(m, s.flattenMap(info.Key, nested))
+		} else {
+			m[info.Key] = def
+		}
+	}
+
+	s.copyFlat(m, s.baseData)
+
+	for key, sf := range s.secretFiles {
+		m[key] = sf.value
+	}
+
+	s.copyFlat(m, s.fileData)
+
+	for _, data := range s.sourceData {
+		s.copyFlat(m, data)
 	}
 
-	maps.Copy(m, flattenMap("", s.fileData))
+	s.resolveFeatures(m)
+
+	// Only default, file, and source values are expanded; env vars and
+	// flags have already been through the shell.
+	for _, info := range s.infos {
+		if v, ok := m[info.Key].(string); ok && info.Expand {
+			m[info.Key] = os.ExpandEnv(v)
+			s.audit(AuditHook, info.Key, "expanded env references")
+		}
+	}
 
 	for _, info := range s.infos {
+		// A URL in the env var of a url_components struct sets the fields
+		// below their own env vars.
+		name, val, ok, err := s.urlComponent(info)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if ok {
+			s.replaceKey(m, info.Key, val)
+			s.audit(AuditEnv, name, "set %s from URL", info.Key)
+		}
+
 		if info.Env == skipTagValue || info.Env == "" {
 			continue
 		}
 
-		if val, ok := os.LookupEnv(info.Env); ok {
-			m[info.Key] = val
+		if name, val, ok := lookupEnv(info); ok {
+			s.replaceKey(m, info.Key, val)
+			s.audit(AuditEnv, name, "set %s", info.Key)
 		}
 	}
 
@@ -466,20 +1216,33 @@ func (s *StructConfig) buildMerged() (map[string]any, error) {
 			continue
 		}
 
-		f := s.flags.Lookup(info.Flag)
-		if f == nil || !f.Changed {
-			continue
+		val, _, ok, err := s.changedFlag(info)
+		if err != nil {
+			return nil, nil, fmt.Errorf("source flag --%s (field %q, key %q): %w", info.Flag, info.Name, info.Key, err)
 		}
 
-		val, err := readFlagValue(s.flags, info)
-		if err != nil {
-			return nil, fmt.Errorf("source flag --%s (field %q, key %q): %w", info.Flag, info.Name, info.Key, err)
+		if ok {
+			s.replaceKey(m, info.Key, val)
+			s.audit(AuditFlag, "--"+info.Flag, "set %s", info.Key)
 		}
+	}
+
+	attribution := s.buildSourceAttribution()
+
+	if err := s.resolveSecrets(m, attribution); err != nil {
+		return nil, nil, err
+	}
 
-		m[info.Key] = val
+	for _, info := range s.infos {
+		if v, ok := m[info.Key]; ok && info.durationUnit != 0 {
+			m[info.Key] = applyDurationUnit(v, info.durationUnit)
+			s.audit(AuditHook, info.Key, "applied duration unit %s", info.tag.Get(tagDurationUnit))
+		}
 	}
 
-	return m, nil
+	s.dropAbsentSections(m)
+
+	return m, attribution, nil
 }
 
 // readFlagValue reads a typed value from a pflag flag based on the field's reflect type.
@@ -489,6 +1252,10 @@ func readFlagValue(flags *pflag.FlagSet, info varInfo) (any, error) {
 		typ = typ.Elem()
 	}
 
+	if isScalarType(typ) || typ == fileModeType {
+		return flags.Lookup(info.Flag).Value.String(), nil
+	}
+
 	switch typ.Kind() {
 	case reflect.String:
 		return flags.GetString(info.Flag)
@@ -503,7 +1270,7 @@ func readFlagValue(flags *pflag.FlagSet, info varInfo) (any, error) {
 	case reflect.Int32:
 		return flags.GetInt32(info.Flag)
 	case reflect.Int64:
-		if typ.PkgPath() == "time" && typ.Name() == "Duration" {
+		if typ == durationType {
 			return flags.GetDuration(info.Flag)
 		}
 
@@ -523,7 +1290,7 @@ func readFlagValue(flags *pflag.FlagSet, info varInfo) (any, error) {
 	case reflect.Float64:
 		return flags.GetFloat64(info.Flag)
 	case reflect.Slice:
-		return flags.GetStringSlice(info.Flag)
+		return readSliceFlag(flags, info.Flag)
 	case reflect.Map:
 		switch typ.Elem().Kind() {
 		case reflect.String:
@@ -541,21 +1308,99 @@ func readFlagValue(flags *pflag.FlagSet, info varInfo) (any, error) {
 }
 
 func (s *StructConfig) unmarshalInto(m map[string]any, target any) error {
-	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+	decoder, err := s.newDecoder(target)
+	if err != nil {
+		return err
+	}
+
+	// With case-sensitive keys, keys that differ from a field key only in
+	// case are dropped, since mapstructure matches field names regardless
+	// of case.
+	if s.options.CaseSensitiveKeys {
+		m = maps.Clone(m)
+		maps.DeleteFunc(m, func(key string, _ any) bool { return !s.isKnownKey(key) })
+	}
+
+	return decoder.Decode(s.expandKeys(m))
+}
+
+func (s *StructConfig) newDecoder(target any) (*mapstructure.Decoder, error) {
+	return mapstructure.NewDecoder(&mapstructure.DecoderConfig{
 		Result:           target,
 		TagName:          s.options.Tags.FileTag,
 		WeaklyTypedInput: true,
 		DecodeHook: mapstructure.ComposeDecodeHookFunc(
-			mapstructure.StringToTimeDurationHookFunc(),
+			s.compositeNameHookFunc(),
+			scalarHookFunc(),
+			fileModeHookFunc(),
+			durationHookFunc(),
 			stringToTypedSliceHookFunc(","),
 			stringToMapStringHookFunc("=", ","),
 		),
 	})
+}
+
+// decodeValue decodes a single raw source value into a value of type typ using
+// the same hooks as unmarshalInto.
+func (s *StructConfig) decodeValue(raw any, typ reflect.Type) (any, error) {
+	target := reflect.New(typ)
+
+	decoder, err := s.newDecoder(target.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = decoder.Decode(raw); err != nil {
+		return nil, err
+	}
+
+	return target.Elem().Interface(), nil
+}
+
+// UnmarshalKey decodes the effective value of key into out, with the same
+// hooks and file tag handling as Process. key may name a section, such as
+// "db", to decode its fields into a separate struct, or a single field.
+// Secret values are decoded as is.
+func (s *StructConfig) UnmarshalKey(key string, out any) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.merged == nil {
+		return ErrNotProcessed
+	}
+
+	key = s.foldKey(key)
+
+	var raw any
+
+	if v, ok := s.merged[key]; ok {
+		raw = v
+	} else {
+		sub := make(map[string]any)
+
+		for k, v := range s.merged {
+			if rest, ok := strings.CutPrefix(k, key+s.options.KeyDelimiter); ok {
+				sub[rest] = v
+			}
+		}
+
+		if len(sub) == 0 {
+			return fmt.Errorf("unmarshal key %q: no value for key", key)
+		}
+
+		raw = s.expandKeys(sub)
+	}
+
+	decoder, err := s.newDecoder(out)
 	if err != nil {
 		return err
 	}
 
-	return decoder.Decode(expandKeys(m))
+	if err = decoder.Decode(raw); err != nil {
+		return fmt.Errorf("unmarshal key %q: %w", key, err)
+	}
+
+	return nil
 }
 
 func initNilMaps(v reflect.Value) {
@@ -590,48 +1435,153 @@ func initNilMaps(v reflect.Value) {
 	}
 }
 
+// checkRequired verifies that some source set every required field. A value
+// that is set but empty, such as an env var set to "", only satisfies a field
+// tagged allow_empty.
+// Every unset field is reported, in one error joining them.
 func (s *StructConfig) checkRequired(merged map[string]any) error {
+	var errs []error
+
 	for _, info := range s.infos {
-		if info.Required {
-			if _, ok := merged[info.Key]; !ok {
-				return fmt.Errorf("value for field %s(%s) is required", info.Name, info.Key)
+		// Required fields of an absent optional section, or of a struct
+		// whose gate is off, are not needed.
+		if !info.Required || s.isAbsent(info.Key) || s.disabled(info, merged) {
+			continue
+		}
+
+		// required_msg tells the operator how to provide the value.
+		hint := ""
+		if msg := info.tag.Get(tagRequiredMsg); msg != "" {
+			hint = ": " + msg
+		}
+
+		v, ok := merged[info.Key]
+		if !ok {
+			if !s.hasNestedKey(merged, info.Key) {
+				errs = append(errs, fmt.Errorf("value for field %s(%s) is required%s", info.Name, info.Key, hint))
 			}
+
+			continue
+		}
+
+		if !info.AllowEmpty && isEmptyValue(v) {
+			errs = append(errs, fmt.Errorf("value for field %s(%s) is required but set to an empty value%s", info.Name, info.Key, hint))
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
-// MustProcess is the same as Process but exits 0 for built-in control-flow
-// flags (version/default-config/debug) and panics for all other errors.
-func MustProcess(prefix string, spec any) {
-	if out, err := Process(prefix, spec); err != nil {
-		if out != "" {
-			fmt.Print(out)
+// hasNestedKey reports whether merged holds a flattened entry below key, as
+// a map field set from a config file does.
+func (s *StructConfig) hasNestedKey(merged map[string]any, key string) bool {
+	for k := range merged {
+		if strings.HasPrefix(k, key+s.options.KeyDelimiter) {
+			return true
 		}
+	}
 
-		if errors.Is(err, ErrVersionCalled) || errors.Is(err, ErrDefaultConfigCalled) || errors.Is(err, ErrDebugCalled) {
-			os.Exit(0)
-		}
+	return false
+}
 
-		panic(err)
+// isEmptyValue reports whether a merged value is nil, an empty string, or an
+// empty slice or map.
+func isEmptyValue(v any) bool {
+	if v == nil {
+		return true
 	}
+
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return rv.Len() == 0
+	default:
+		return false
+	}
+}
+
+// MustProcess is the same as Process but exits 0 for built-in control-flow
+// flags (help/version/default-config/debug/diff-defaults/print-env) and panics
+// with a *ProcessError for all other errors.
+func MustProcess(prefix string, spec any) {
+	NewStructConfig(nil).MustProcess(prefix, spec)
 }
 
 // MustProcess is the same as Process but exits 0 for built-in control-flow
-// flags (version/default-config/debug) and panics for all other errors.
+// flags (help/version/default-config/debug/diff-defaults/print-env) and panics
+// with a *ProcessError for all other errors.
 func (s *StructConfig) MustProcess(prefix string, spec any) {
 	if out, err := s.Process(prefix, spec); err != nil {
 		if out != "" {
 			fmt.Print(out)
 		}
 
-		if errors.Is(err, ErrVersionCalled) || errors.Is(err, ErrDefaultConfigCalled) || errors.Is(err, ErrDebugCalled) {
+		if isControlFlowError(err) {
 			os.Exit(0)
 		}
 
-		panic(err)
+		panic(newProcessError(prefix, err))
+	}
+}
+
+// ProcessError is the value MustProcess panics with, so frameworks that
+// recover from panics can render each problem instead of a single string.
+type ProcessError struct {
+	// Prefix is the prefix passed to MustProcess.
+	Prefix string
+	// Errs holds the errors reported by Process, such as one per missing
+	// required field.
+	Errs []error
+}
+
+func (e *ProcessError) Error() string {
+	return errors.Join(e.Errs...).Error()
+}
+
+func (e *ProcessError) Unwrap() []error {
+	return e.Errs
+}
+
+// newProcessError splits err into the errors it joins.
+func newProcessError(prefix string, err error) *ProcessError {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return &ProcessError{Prefix: prefix, Errs: joined.Unwrap()}
+	}
+
+	return &ProcessError{Prefix: prefix, Errs: []error{err}}
+}
+
+// usage is installed as the flag set's Usage func. pflag calls it on -h/--help
+// and parse errors; with ContinueOnError Process reports help itself, so usage
+// only prints when pflag is about to exit or panic.
+func (s *StructConfig) usage() {
+	if s.options.FlagErrorHandling != pflag.ContinueOnError {
+		fmt.Fprint(s.flags.Output(), s.helpText())
+	}
+}
+
+func (s *StructConfig) processHelp() (string, error) {
+	help := s.helpText()
+
+	if s.options.HelpFunc != nil {
+		s.queue(func() { s.options.HelpFunc(help) })
+		return "", ErrHelpRequested
 	}
+
+	return help, ErrHelpRequested
+}
+
+// isControlFlowError reports whether err was returned because a built-in flag
+// that prints output and stops processing was set.
+func isControlFlowError(err error) bool {
+	return errors.Is(err, ErrVersionCalled) ||
+		errors.Is(err, ErrDefaultConfigCalled) ||
+		errors.Is(err, ErrDebugCalled) ||
+		errors.Is(err, ErrDiffDefaultsCalled) ||
+		errors.Is(err, ErrPrintEnvCalled) ||
+		errors.Is(err, ErrAuditCalled) ||
+		errors.Is(err, ErrHelpRequested)
 }
 
 func (s *StructConfig) addBuiltInFlags() error {
@@ -640,7 +1590,7 @@ func (s *StructConfig) addBuiltInFlags() error {
 		return err
 	}
 
-	err = s.addBuiltInStringFlag(s.options.FlagNames.ConfigType, s.options.FlagShorts.ConfigType, s.options.ConfigType, "config file type")
+	err = s.addBuiltInStringFlag(s.options.FlagNames.ConfigType, s.options.FlagShorts.ConfigType, s.options.ConfigType, "config file type: toml, yaml, or json")
 	if err != nil {
 		return err
 	}
@@ -650,12 +1600,55 @@ func (s *StructConfig) addBuiltInFlags() error {
 		return err
 	}
 
-	err = s.addBuiltInBoolFlag(s.options.FlagNames.Debug, s.options.FlagShorts.Debug, "print config debug info and exit")
+	err = s.addBuiltInStringFlag(s.options.FlagNames.Debug, s.options.FlagShorts.Debug, "", "print config debug info and exit: keys, values (secrets redacted), or full")
+	if err != nil {
+		return err
+	}
+
+	if f := s.flags.Lookup(s.options.FlagNames.Debug); f != nil {
+		f.NoOptDefVal = debugValues
+
+		err = s.addBuiltInBoolFlag(s.options.FlagNames.DebugFull, s.options.FlagShorts.DebugFull, "confirm that --"+f.Name+"=full prints secret values")
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.addBuiltInBoolFlag(s.options.FlagNames.DiffDefaults, s.options.FlagShorts.DiffDefaults, "print config keys that differ from their defaults and exit")
+	if err != nil {
+		return err
+	}
+
+	err = s.addBuiltInBoolFlag(s.options.FlagNames.PrintEnv, s.options.FlagShorts.PrintEnv, "print the environment variables read by the application and exit")
+	if err != nil {
+		return err
+	}
+
+	err = s.addBuiltInStringFlag(s.options.FlagNames.WriteConfig, s.options.FlagShorts.WriteConfig, "", "write the effective config to the given path")
+	if err != nil {
+		return err
+	}
+
+	err = s.addBuiltInStringFlag(s.options.FlagNames.Profile, s.options.FlagShorts.Profile, s.options.Profile, "profile selecting default_<profile> tag defaults")
+	if err != nil {
+		return err
+	}
+
+	err = s.addBuiltInBoolFlag(s.options.FlagNames.Audit, s.options.FlagShorts.Audit, "print how the config was resolved and exit")
 	if err != nil {
 		return err
 	}
 
-	return s.addBuiltInBoolFlag(s.options.FlagNames.Version, s.options.FlagShorts.Version, "print application version info and exit")
+	err = s.addBuiltInStringFlag(s.options.FlagNames.Version, s.options.FlagShorts.Version, "", "print application version info and exit: text or json")
+	if err != nil {
+		return err
+	}
+
+	if f := s.flags.Lookup(s.options.FlagNames.Version); f != nil {
+		f.NoOptDefVal = versionText
+	}
+
+	return nil
 }
 
 func (s *StructConfig) addBuiltInBoolFlag(name, short, desc string) error {
@@ -676,6 +1669,22 @@ func (s *StructConfig) addBuiltInBoolFlag(name, short, desc string) error {
 	return nil
 }
 
+// boolFlagValue maps the true and false spellings that a built-in flag took
+// as a bool flag, such as --debug=true, to on and to "" for off. Other values
+// are returned unchanged.
+func boolFlagValue(val, on string) string {
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return val
+	}
+
+	if b {
+		return on
+	}
+
+	return ""
+}
+
 func (s *StructConfig) addBuiltInStringFlag(name, short, defVal, desc string) error {
 	if name == "" || name == skipBuiltInFlagValue {
 		return nil
@@ -699,21 +1708,22 @@ func (s *StructConfig) processVersionFlag() (string, error) {
 		return "", nil
 	}
 
-	showVersion, err := s.flags.GetBool(s.options.FlagNames.Version)
+	format, err := s.flags.GetString(s.options.FlagNames.Version)
 	if err != nil {
 		return "", err
 	}
 
-	if showVersion {
-		v := s.options.VersionFunc()
-		if !strings.HasSuffix(v, "\n") {
-			v += "\n"
-		}
+	format = boolFlagValue(format, versionText)
+	if format == "" {
+		return "", nil
+	}
 
-		return v, ErrVersionCalled
+	v, err := s.formatVersion(format)
+	if err != nil {
+		return "", err
 	}
 
-	return "", nil
+	return v, ErrVersionCalled
 }
 
 func (s *StructConfig) processDefaultConfigFlag() (string, error) {
@@ -730,58 +1740,98 @@ func (s *StructConfig) processDefaultConfigFlag() (string, error) {
 		return "", nil
 	}
 
+	out, err := s.defaultConfig()
+	if err != nil {
+		return "", err
+	}
+
+	return out, ErrDefaultConfigCalled
+}
+
+// defaultConfig encodes a config file holding the default of every field, or
+// its zero value when it has none, annotated with the field comments.
+func (s *StructConfig) defaultConfig() (string, error) {
 	defaults := make(map[string]any, len(s.infos))
 
 	for _, info := range s.infos {
-		if info.Default != "" {
-			defaults[info.Key] = info.Default
+		if def, ok := info.defaultRaw(); ok {
+			defaults[info.Key] = def
 		} else {
 			defaults[info.Key] = reflect.Zero(info.typ).Interface()
 		}
 	}
 
-	out, err := s.dumpConfig(expandKeys(defaults))
-	if err != nil {
-		return "", err
-	}
-
-	return out, ErrDefaultConfigCalled
+	return s.dumpAnnotatedConfig(s.expandKeys(defaults))
 }
 
 // buildSourceAttribution walks each known field and records the highest-priority
-// source that provided its value (default < file < env < flag).
+// source that provided its value (default < default config < secrets dir <
+// file < sources < env < flag).
 func (s *StructConfig) buildSourceAttribution() []keySource {
-	fileFlat := flattenMap("", s.fileData)
+	baseFlat := s.flatten(s.baseData)
+	fileFlat := s.flatten(s.fileData)
 	result := make([]keySource, 0, len(s.infos))
 
+	sourceFlat := make([]map[string]any, len(s.sourceData))
+	for i, data := range s.sourceData {
+		sourceFlat[i] = s.flatten(data)
+	}
+
 	for _, info := range s.infos {
-		ks := keySource{Key: info.Key, Value: "<unset>", Source: sourceUnset}
+		ks := keySource{Key: info.Key, Value: "<unset>", origin: Origin{Kind: OriginUnset}}
 
 		if info.Default != "" {
 			ks.Value = info.Default
-			ks.Source = sourceDefault
+			ks.origin = Origin{Kind: OriginDefault}
+		}
+
+		if v, ok := baseFlat[info.Key]; ok {
+			ks.Value = fmt.Sprint(v)
+			ks.origin = Origin{Kind: OriginDefaultConfig}
+		}
+
+		if sf, ok := s.secretFiles[info.Key]; ok {
+			ks.Value = sf.value
+			ks.origin = Origin{Kind: OriginSecretsDir, Name: sf.path}
 		}
 
 		if _, ok := fileFlat[info.Key]; ok {
 			ks.Value = fmt.Sprint(fileFlat[info.Key])
-			ks.Source = sourceFile
+			ks.origin = Origin{Kind: OriginFile}
+		}
+
+		for i, data := range sourceFlat {
+			if v, ok := data[info.Key]; ok {
+				ks.Value = fmt.Sprint(v)
+				ks.origin = Origin{Kind: OriginSource, Name: sourceName(s.options.Sources[i])}
+			}
+		}
+
+		if v, ok := s.featureData[info.Key]; ok {
+			ks.Value = fmt.Sprint(v)
+			ks.origin = Origin{Kind: OriginFeature, Name: info.tag.Get(tagFeature)}
+		}
+
+		if name, val, ok, err := s.urlComponent(info); ok && err == nil {
+			ks.Value = val
+			ks.origin = Origin{Kind: OriginEnv, Name: name}
 		}
 
 		if info.Env != skipTagValue && info.Env != "" {
-			if val, ok := os.LookupEnv(info.Env); ok {
+			if name, val, ok := lookupEnv(info); ok {
 				ks.Value = val
-				ks.Source = fmt.Sprintf("%s (%s)", sourceEnv, info.Env)
+				ks.origin = Origin{Kind: OriginEnv, Name: name}
 			}
 		}
 
 		if info.Flag != skipTagValue && info.Flag != "" {
-			f := s.flags.Lookup(info.Flag)
-			if f != nil && f.Changed {
-				ks.Value = f.Value.String()
-				ks.Source = fmt.Sprintf("%s (--%s)", sourceFlag, info.Flag)
+			if val, name, ok, err := s.changedFlag(info); ok && err == nil {
+				ks.Value = fmt.Sprint(val)
+				ks.origin = Origin{Kind: OriginFlag, Name: "--" + name}
 			}
 		}
 
+		ks.Source = ks.origin.String()
 		result = append(result, ks)
 	}
 
@@ -790,69 +1840,153 @@ func (s *StructConfig) buildSourceAttribution() []keySource {
 
 // formatSourceTable renders a fixed-width table of key/value/source rows.
 func formatSourceTable(sources []keySource) string {
-	const (
-		hKey    = "KEY"
-		hValue  = "VALUE"
-		hSource = "SOURCE"
-	)
+	rows := make([][]string, 0, len(sources))
+	for _, ks := range sources {
+		rows = append(rows, []string{ks.Key, ks.Value, ks.Source})
+	}
 
-	wKey, wValue, wSource := len(hKey), len(hValue), len(hSource)
+	return formatTable([]string{"KEY", "VALUE", "SOURCE"}, rows)
+}
 
-	for _, ks := range sources {
-		if l := len(ks.Key); l > wKey {
-			wKey = l
+func (s *StructConfig) processDebugFlag(merged map[string]any) (string, error) {
+	if s.options.FlagNames.Debug == skipBuiltInFlagValue {
+		return "", nil
+	}
+
+	level, err := s.flags.GetString(s.options.FlagNames.Debug)
+	if err != nil {
+		return "", err
+	}
+
+	level = boolFlagValue(level, debugValues)
+
+	configFile := s.configFile
+	if configFile == "" {
+		configFile = "none"
+	}
+
+	switch level {
+	case "":
+		return "", nil
+	case debugKeys:
+		return "config file: " + configFile + "\n\n" + s.formatBindingTable(), ErrDebugCalled
+	case debugValues:
+		configOut, err := s.dumpConfig(s.expandKeys(s.redacted(merged)))
+		if err != nil {
+			return "", err
 		}
 
-		if l := len(ks.Value); l > wValue {
-			wValue = l
+		table := formatSourceTable(s.redactedSourceAttribution())
+
+		return configOut + "\nconfig file: " + configFile + "\n\n" + table, ErrDebugCalled
+	case debugFull:
+		confirmed, _ := s.flags.GetBool(s.options.FlagNames.DebugFull)
+		if !confirmed {
+			return "", fmt.Errorf("--%s=full prints secret values; add --%s to confirm", s.options.FlagNames.Debug, s.options.FlagNames.DebugFull)
 		}
 
-		if l := len(ks.Source); l > wSource {
-			wSource = l
+		configOut, err := s.dumpConfig(s.expandKeys(merged))
+		if err != nil {
+			return "", err
 		}
+
+		table := formatSourceTable(s.attribution)
+
+		return configOut + "\nconfig file: " + configFile + "\n\n" + table, ErrDebugCalled
+	default:
+		return "", fmt.Errorf("bad --%s value %q: want %s, %s, or %s", s.options.FlagNames.Debug, level, debugKeys, debugValues, debugFull)
 	}
+}
 
-	var b strings.Builder
+// formatBindingTable lists the env var, flag, and source of every key,
+// without values, for --debug=keys.
+func (s *StructConfig) formatBindingTable() string {
+	rows := make([][]string, 0, len(s.attribution))
 
-	rowFmt := fmt.Sprintf("%%-%ds  %%-%ds  %%-%ds\n", wKey, wValue, wSource)
-	sepFmt := fmt.Sprintf("%%-%ds  %%-%ds  %%-%ds\n", wKey, wValue, wSource)
+	for i, info := range s.infos {
+		var env, flag string
 
-	fmt.Fprintf(&b, rowFmt, hKey, hValue, hSource)
-	fmt.Fprintf(&b, sepFmt,
-		strings.Repeat("-", wKey),
-		strings.Repeat("-", wValue),
-		strings.Repeat("-", wSource),
-	)
+		if info.Env != skipTagValue {
+			env = info.Env
+		}
 
-	for _, ks := range sources {
-		fmt.Fprintf(&b, rowFmt, ks.Key, ks.Value, ks.Source)
+		if f := helpFlag(info); f != "" {
+			flag = "--" + f
+		}
+
+		rows = append(rows, []string{info.Key, env, flag, s.attribution[i].origin.String()})
 	}
 
-	return b.String()
+	return formatTable([]string{"KEY", "ENV", "FLAG", "SOURCE"}, rows)
 }
 
-func (s *StructConfig) processDebugFlag(merged map[string]any) (string, error) {
-	if s.options.FlagNames.Debug == skipBuiltInFlagValue {
-		return "", nil
+func (s *StructConfig) processWriteConfigFlag() error {
+	if s.options.FlagNames.WriteConfig == skipBuiltInFlagValue {
+		return nil
 	}
 
-	printDebug, err := s.flags.GetBool(s.options.FlagNames.Debug)
+	path, err := s.flags.GetString(s.options.FlagNames.WriteConfig)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	if !printDebug {
-		return "", nil
+	if path == "" {
+		return nil
 	}
 
-	configOut, err := s.dumpConfig(expandKeys(merged))
+	return s.writeConfig(expandPath(path))
+}
+
+// WriteConfig writes the effective configuration merged by Process to path in
+// the active config format. Values of fields tagged secret are redacted.
+func (s *StructConfig) WriteConfig(path string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.writeConfig(path)
+}
+
+func (s *StructConfig) writeConfig(path string) error {
+	out, err := s.dumpConfig(s.expandKeys(s.redacted(s.merged)))
 	if err != nil {
-		return "", err
+		return fmt.Errorf("write config: %w", err)
 	}
 
-	table := formatSourceTable(s.buildSourceAttribution())
+	// The file is replaced by a rename so readers never see it half
+	// written when it is rewritten on reload.
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.WriteString(out); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write config: %w", err)
+	}
 
-	return configOut + "\n" + table, ErrDebugCalled
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+
+	return nil
+}
+
+// redacted returns a copy of m with the values of secret fields replaced.
+func (s *StructConfig) redacted(m map[string]any) map[string]any {
+	out := maps.Clone(m)
+
+	for _, info := range s.infos {
+		if _, ok := out[info.Key]; ok && info.Secret {
+			out[info.Key] = redactedValue
+		}
+	}
+
+	return out
 }
 
 func (s *StructConfig) dumpConfig(config map[string]any) (string, error) {
@@ -867,6 +2001,13 @@ func (s *StructConfig) dumpConfig(config map[string]any) (string, error) {
 		if err := yaml.NewEncoder(&buf).Encode(config); err != nil {
 			return "", err
 		}
+	case "json":
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(config); err != nil {
+			return "", err
+		}
 	default:
 		return "", fmt.Errorf("unsupported config type %s", s.options.ConfigType)
 	}
@@ -879,7 +2020,7 @@ func (s *StructConfig) getConfigPathAndType() (string, string, error) {
 		return "", "", nil
 	}
 
-	path, err := s.flags.GetString(s.options.FlagNames.ConfigPath)
+	path, err := s.builtInFlagOrEnv(s.options.FlagNames.ConfigPath, envConfigPathSuffix)
 	if err != nil {
 		return "", "", err
 	}
@@ -888,7 +2029,7 @@ func (s *StructConfig) getConfigPathAndType() (string, string, error) {
 		return path, "", nil
 	}
 
-	configType, err := s.flags.GetString(s.options.FlagNames.ConfigType)
+	configType, err := s.builtInFlagOrEnv(s.options.FlagNames.ConfigType, envConfigTypeSuffix)
 	if err != nil {
 		return "", "", err
 	}
@@ -896,6 +2037,46 @@ func (s *StructConfig) getConfigPathAndType() (string, string, error) {
 	return path, configType, nil
 }
 
+// builtInFlagOrEnv returns the value of a built-in string flag, falling back to
+// the <PREFIX>_<suffix> environment variable when the flag was not passed.
+func (s *StructConfig) builtInFlagOrEnv(name, suffix string) (string, error) {
+	val, err := s.flags.GetString(name)
+	if err != nil {
+		return "", err
+	}
+
+	if s.flags.Changed(name) || s.prefix == "" {
+		return val, nil
+	}
+
+	if envVal, ok := os.LookupEnv(strings.ToUpper(s.prefix + "_" + suffix)); ok {
+		return envVal, nil
+	}
+
+	return val, nil
+}
+
+// readDefaultConfig parses Options.DefaultConfig into the lowest file layer.
+func (s *StructConfig) readDefaultConfig() error {
+	if len(s.options.DefaultConfig) == 0 {
+		return nil
+	}
+
+	raw, err := unmarshalConfig(s.options.DefaultConfigType, s.options.DefaultConfig)
+	if err != nil {
+		return newConfigParseError("Options.DefaultConfig", s.options.DefaultConfig, err)
+	}
+
+	if err = s.decryptValues(raw); err != nil {
+		return err
+	}
+
+	s.baseData = raw
+	s.audit(AuditConfigFile, "Options.DefaultConfig", "read %d keys", len(s.flattenMap("", raw)))
+
+	return nil
+}
+
 func (s *StructConfig) readConfigFile(path string) error {
 	if path == "" {
 		return nil
@@ -906,39 +2087,198 @@ func (s *StructConfig) readConfigFile(path string) error {
 		return err
 	}
 
+	if len(s.options.ConfigPublicKey) > 0 {
+		if err = verifyConfigSignature(path, data, s.options.ConfigPublicKey); err != nil {
+			return err
+		}
+	}
+
+	raw, err := unmarshalConfig(s.options.ConfigType, data)
+	if err != nil {
+		return newConfigParseError(path, data, err)
+	}
+
+	s.fileData = raw
+	s.configFile = path
+	s.audit(AuditConfigFile, path, "read %d keys", len(s.flattenMap("", raw)))
+
+	return nil
+}
+
+// ConfigParseError is returned by Process and Reload when the config file is
+// not valid in the configured format.
+type ConfigParseError struct {
+	Path string
+	// Line and Column locate the error, starting at 1. They are 0 when the
+	// decoder does not report a position.
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *ConfigParseError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("parse %s: %v", e.Path, e.Err)
+	}
+
+	return fmt.Sprintf("parse %s:%d:%d: %v", e.Path, e.Line, e.Column, e.Err)
+}
+
+func (e *ConfigParseError) Unwrap() error {
+	return e.Err
+}
+
+// newConfigParseError wraps a decode error of data with its position, for
+// the decoders that report one.
+func newConfigParseError(path string, data []byte, err error) *ConfigParseError {
+	pe := &ConfigParseError{Path: path, Err: err}
+
+	var (
+		tomlErr *toml.DecodeError
+		jsonErr *json.SyntaxError
+	)
+
+	switch {
+	case errors.As(err, &tomlErr):
+		pe.Line, pe.Column = tomlErr.Position()
+	case errors.As(err, &jsonErr):
+		// Offset counts the bytes read up to and including the bad one.
+		before := data[:jsonErr.Offset]
+		pe.Line = bytes.Count(before, []byte("\n")) + 1
+		pe.Column = len(before) - bytes.LastIndexByte(before, '\n') - 1
+	}
+
+	return pe
+}
+
+// unmarshalConfig decodes a config document of the given type.
+func unmarshalConfig(configType string, data []byte) (map[string]any, error) {
 	var raw map[string]any
 
-	switch s.options.ConfigType {
+	switch configType {
 	case "toml":
-		if err = toml.Unmarshal(data, &raw); err != nil {
-			return err
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return nil, err
 		}
 	case "yaml":
-		if err = yaml.Unmarshal(data, &raw); err != nil {
-			return err
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	case "json":
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
 		}
 	default:
-		return fmt.Errorf("unsupported config type %q", s.options.ConfigType)
+		return nil, fmt.Errorf("unsupported config type %q", configType)
 	}
 
-	s.fileData = raw
+	return raw, nil
+}
 
-	return nil
+// findConfigFile returns the first existing config file in Options.SearchPaths,
+// or an empty string when none is found.
+func (s *StructConfig) findConfigFile() string {
+	for _, dir := range s.options.SearchPaths {
+		dir = expandPath(dir)
+
+		for _, ext := range configExtensions(s.options.ConfigType) {
+			path := filepath.Join(dir, s.options.ConfigName+ext)
+
+			fi, err := os.Stat(path)
+			if err == nil && !fi.IsDir() {
+				s.audit(AuditConfigFile, path, "found")
+				return path
+			}
+
+			switch {
+			case err == nil:
+				s.audit(AuditConfigFile, path, "skipped: is a directory")
+			case !errors.Is(err, fs.ErrNotExist):
+				s.warn(Warning{Kind: WarningIgnoredFileError, Key: path, Message: err.Error()})
+				s.audit(AuditConfigFile, path, "skipped: %v", err)
+			default:
+				s.audit(AuditConfigFile, path, "not found")
+			}
+		}
+	}
+
+	return ""
+}
+
+// expandPath expands environment variables and a leading ~ in path the way
+// a shell would for an unquoted argument.
+func expandPath(path string) string {
+	path = os.ExpandEnv(path)
+
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = home + path[1:]
+		}
+	}
+
+	return path
 }
 
-// flattenMap converts a nested map into a flat dot-keyed map with lowercase keys.
-func flattenMap(prefix string, m map[string]any) map[string]any {
+// configExtensions returns the file extensions tried for a config type.
+func configExtensions(configType string) []string {
+	switch configType {
+	case "yaml":
+		return []string{".yaml", ".yml"}
+	default:
+		return []string{"." + configType}
+	}
+}
+
+// ConfigFileUsed returns the path of the config file loaded by Process, or an
+// empty string when no config file was read.
+func (s *StructConfig) ConfigFileUsed() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.configFile
+}
+
+// DefaultSearchPaths returns the conventional config directories for app:
+// the working directory, /etc/<app>/ and $XDG_CONFIG_HOME/<app>/.
+func DefaultSearchPaths(app string) []string {
+	paths := []string{"./", filepath.Join("/etc", app) + "/"}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+
+	if configHome != "" {
+		paths = append(paths, filepath.Join(configHome, app)+"/")
+	}
+
+	return paths
+}
+
+// flattenMap converts a nested map into a flat dot-keyed map with lowercase
+// keys, or keys as written with Options.CaseSensitiveKeys.
+func (s *StructConfig) flattenMap(prefix string, m map[string]any) map[string]any {
+	return s.flattenMapRaw(prefix, m, nil)
+}
+
+// flattenMapRaw is flattenMap, except that subtrees whose key satisfies raw are
+// kept as a single verbatim value.
+func (s *StructConfig) flattenMapRaw(prefix string, m map[string]any, raw func(key string) bool) map[string]any {
 	out := make(map[string]any)
 
 	for k, v := range m {
-		key := strings.ToLower(k)
-		if prefix != "" {
-			key = prefix + "." + key
-		}
+		key := s.keyPath(prefix, s.foldKey(k))
 
-		if nested, ok := v.(map[string]any); ok {
-			maps.Copy(out, flattenMap(key, nested))
-		} else {
+		nested, ok := v.(map[string]any)
+
+		switch {
+		case ok && raw != nil && raw(key):
+			out[key] = maps.Clone(nested)
+		case ok:
+			maps.Copy(out, s.flattenMapRaw(key, nested, raw))
+		default:
 			out[key] = v
 		}
 	}
@@ -946,12 +2286,119 @@ func flattenMap(prefix string, m map[string]any) map[string]any {
 	return out
 }
 
+// flatten flattens a source layer, keeping RawSection subtrees intact.
+func (s *StructConfig) flatten(m map[string]any) map[string]any {
+	return s.flattenMapRaw("", m, s.isRawKey)
+}
+
+// flagName derives the long flag name of a field named name in the struct
+// whose derived flag name is prefix. With split, the words of name are
+// joined with dashes, so MaxConns becomes max-conns.
+func (s *StructConfig) flagName(prefix, name string, split bool) string {
+	if s.options.FlagNameFunc != nil {
+		return s.options.FlagNameFunc(prefix, name)
+	}
+
+	if split {
+		name = strings.ReplaceAll(splitWords(name, true, s.options.Acronyms), "_", "-")
+	}
+
+	name = strings.ToLower(strings.ReplaceAll(name, s.options.KeyDelimiter, "-"))
+	if prefix == "" {
+		return name
+	}
+
+	return prefix + "-" + name
+}
+
+// queue runs f once s.mu, held for writing, is released by unlock, so
+// callbacks may call the exported readers.
+func (s *StructConfig) queue(f func()) {
+	s.pending = append(s.pending, f)
+}
+
+// unlock releases s.mu and runs the callbacks queued while it was held.
+func (s *StructConfig) unlock() {
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	for _, f := range pending {
+		f()
+	}
+}
+
+// keyPath joins a parent key and a child key with Options.KeyDelimiter.
+func (s *StructConfig) keyPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + s.options.KeyDelimiter + key
+}
+
+// foldKey lowercases a key from a config file, source, or default unless
+// Options.CaseSensitiveKeys is set.
+func (s *StructConfig) foldKey(key string) string {
+	if s.options.CaseSensitiveKeys {
+		return key
+	}
+
+	return strings.ToLower(key)
+}
+
+// copyFlat copies the flattened layer data into m. A raw section in data
+// replaces the section from lower layers as a whole.
+func (s *StructConfig) copyFlat(m, data map[string]any) {
+	for k, v := range s.flatten(data) {
+		if s.isRawKey(k) {
+			for existing := range m {
+				if strings.HasPrefix(existing, k+s.options.KeyDelimiter) {
+					delete(m, existing)
+				}
+			}
+		}
+
+		m[k] = v
+	}
+}
+
+// replaceKey sets key to v, dropping the flattened entries of a map value
+// that v replaces as a whole.
+func (s *StructConfig) replaceKey(m map[string]any, key string, v any) {
+	for existing := range m {
+		if strings.HasPrefix(existing, key+s.options.KeyDelimiter) {
+			delete(m, existing)
+		}
+	}
+
+	m[key] = v
+}
+
+func (s *StructConfig) isRawKey(key string) bool {
+	return slices.ContainsFunc(s.infos, func(info varInfo) bool {
+		return info.raw && info.Key == key
+	})
+}
+
+// RawSection captures a config file subtree verbatim, with the original key
+// case, for settings the application passes through without modelling them.
+// A map[string]any field behaves the same way. Raw sections are read from the
+// config file and custom sources only; they get no flag or env var.
+type RawSection map[string]any
+
+func isRawSection(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Map &&
+		typ.Key().Kind() == reflect.String &&
+		typ.Elem().Kind() == reflect.Interface && typ.Elem().NumMethod() == 0
+}
+
 // expandKeys converts a flat dot-keyed map into a nested map for mapstructure.
-func expandKeys(flat map[string]any) map[string]any {
+func (s *StructConfig) expandKeys(flat map[string]any) map[string]any {
 	out := map[string]any{}
 
 	for k, v := range flat {
-		parts := strings.Split(k, ".")
+		parts := strings.Split(k, s.options.KeyDelimiter)
 		cur := out
 
 		for i, p := range parts {
@@ -983,25 +2430,52 @@ func (s *StructConfig) addFlag(v *varInfo) error {
 		return fmt.Errorf("found redefined flag for %q", v.Flag)
 	}
 
+	// pflag panics on longer shorthands instead of returning an error.
+	if len(v.ShortFlag) > 1 || v.ShortFlag != "" && v.ShortFlag[0] >= utf8.RuneSelf {
+		return fmt.Errorf("bad shorthand %q for flag %q: want a single ASCII character", v.ShortFlag, v.Flag)
+	}
+
 	if v.ShortFlag != "" && s.flags.ShorthandLookup(v.ShortFlag) != nil {
 		return fmt.Errorf("found redefined shorthand for %q - define flags for fields", v.ShortFlag)
 	}
 
-	descr := fmt.Sprintf("key: %s, env: %s, default: [%s]", v.Key, v.Env, v.Default)
-	if v.Description != "" {
-		descr += "\n" + v.Description
-	}
+	// Key, env var, and default are shown by the settings table in helpText.
+	descr := v.Description
 
 	typ := v.typ
 	if typ.Kind() == reflect.Pointer {
 		typ = typ.Elem()
 	}
 
+	if newValue, ok := lookupFlagValue(typ); ok {
+		s.flags.VarP(newValue(), v.Flag, v.ShortFlag, descr)
+		return s.applyFlagTags(v)
+	}
+
+	if isScalarType(typ) {
+		s.flags.VarP(&textValue{typ: typ}, v.Flag, v.ShortFlag, descr)
+		return s.applyFlagTags(v)
+	}
+
+	if typ == fileModeType {
+		s.flags.VarP(new(fileModeValue), v.Flag, v.ShortFlag, descr)
+		return s.applyFlagTags(v)
+	}
+
 	switch typ.Kind() {
 	case reflect.String:
 		s.flags.StringP(v.Flag, v.ShortFlag, "", descr)
 	case reflect.Bool:
 		s.flags.BoolP(v.Flag, v.ShortFlag, false, descr)
+
+		if s.options.NegateBoolFlags {
+			neg := negationFlagPrefix + v.Flag
+			if s.flags.Lookup(neg) != nil {
+				return fmt.Errorf("found redefined flag for %q", neg)
+			}
+
+			s.flags.Bool(neg, false, "disable --"+v.Flag)
+		}
 	case reflect.Int:
 		s.flags.IntP(v.Flag, v.ShortFlag, 0, descr)
 	case reflect.Int8:
@@ -1011,8 +2485,8 @@ func (s *StructConfig) addFlag(v *varInfo) error {
 	case reflect.Int32:
 		s.flags.Int32P(v.Flag, v.ShortFlag, 0, descr)
 	case reflect.Int64:
-		if typ.PkgPath() == "time" && typ.Name() == "Duration" {
-			s.flags.DurationP(v.Flag, v.ShortFlag, 0, descr)
+		if typ == durationType {
+			s.flags.VarP(&durationValue{unit: v.durationUnit}, v.Flag, v.ShortFlag, descr)
 		} else {
 			s.flags.Int64P(v.Flag, v.ShortFlag, 0, descr)
 		}
@@ -1031,7 +2505,7 @@ func (s *StructConfig) addFlag(v *varInfo) error {
 	case reflect.Float64:
 		s.flags.Float64P(v.Flag, v.ShortFlag, 0, descr)
 	case reflect.Slice:
-		s.flags.StringSliceP(v.Flag, v.ShortFlag, []string{}, descr)
+		s.addSliceFlag(v, typ.Elem(), descr)
 	case reflect.Map:
 		if typ.Key().Kind() != reflect.String {
 			return fmt.Errorf("unsupported key type for maps %s for flag %s(%s)", typ, v.Name, v.Flag)
@@ -1051,9 +2525,94 @@ func (s *StructConfig) addFlag(v *varInfo) error {
 		return fmt.Errorf("unsupported type %s for flag %s(%s)", typ, v.Name, v.Flag)
 	}
 
+	return s.applyFlagTags(v)
+}
+
+// applyFlagTags checks that the flag registered for v got its shorthand and
+// applies the noopt, flag_deprecated, and short_deprecated tags to it. pflag
+// keeps deprecated flags working but prints the message when they are used.
+func (s *StructConfig) applyFlagTags(v *varInfo) error {
+	if s.flags.Lookup(v.Flag).Shorthand != v.ShortFlag {
+		return fmt.Errorf("shorthand %q could not be applied to flag %q", v.ShortFlag, v.Flag)
+	}
+
+	if value, ok := v.tag.Lookup(tagNoOpt); ok {
+		s.flags.Lookup(v.Flag).NoOptDefVal = value
+	}
+
+	if msg := v.tag.Get(tagFlagDeprecated); msg != "" {
+		if err := s.flags.MarkDeprecated(v.Flag, msg); err != nil {
+			return err
+		}
+
+		if neg := s.negationFlag(*v); neg != nil {
+			if err := s.flags.MarkDeprecated(neg.Name, msg); err != nil {
+				return err
+			}
+		}
+	}
+
+	if msg := v.tag.Get(tagShortDeprecated); msg != "" {
+		if v.ShortFlag == "" {
+			return fmt.Errorf("%s tag on field %s requires a short flag", tagShortDeprecated, v.Name)
+		}
+
+		if err := s.flags.MarkShorthandDeprecated(v.Flag, msg); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// negationFlag returns the --no-<flag> counterpart of a bool field flag, or
+// nil when Options.NegateBoolFlags is off or the field is not a bool.
+func (s *StructConfig) negationFlag(info varInfo) *pflag.Flag {
+	if indirectKind(info.typ) != reflect.Bool || !s.options.NegateBoolFlags {
+		return nil
+	}
+
+	return s.flags.Lookup(negationFlagPrefix + info.Flag)
+}
+
+// changedFlag returns the value and name of the field flag set on the command
+// line, taking a --no-<flag> counterpart into account. ok is false when
+// neither form was given.
+func (s *StructConfig) changedFlag(info varInfo) (value any, name string, ok bool, err error) {
+	f := s.flags.Lookup(info.Flag)
+	set := f != nil && f.Changed
+
+	neg := s.negationFlag(info)
+	if neg == nil || !neg.Changed {
+		if !set {
+			return nil, "", false, nil
+		}
+
+		value, err = readFlagValue(s.flags, info)
+
+		return value, info.Flag, true, err
+	}
+
+	if set {
+		return nil, "", false, fmt.Errorf("flags --%s and --%s cannot be used together", info.Flag, neg.Name)
+	}
+
+	negated, err := s.flags.GetBool(neg.Name)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	return !negated, neg.Name, true, nil
+}
+
+func indirectKind(typ reflect.Type) reflect.Kind {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	return typ.Kind()
+}
+
 func isTrue(s string) bool {
 	b, _ := strconv.ParseBool(s)
 	return b
@@ -1080,7 +2639,16 @@ func stringToTypedSliceHookFunc(sep string) mapstructure.DecodeHookFunc {
 			return []string{}, nil
 		}
 
-		return strings.Split(raw, sep), nil
+		fields, err := splitEscaped(raw, sep, -1, sep)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, field := range fields {
+			fields[i] = unescapeField(field, sep)
+		}
+
+		return fields, nil
 	}
 }
 
@@ -1118,21 +2686,29 @@ func parseDefaultMap[V any](val, kvSep, sep string, convert func(string) (V, err
 		return map[string]V{}, nil
 	}
 
-	ss := strings.Split(val, sep)
+	ss, err := splitEscaped(val, sep, -1, sep, kvSep)
+	if err != nil {
+		return nil, err
+	}
+
 	out := make(map[string]V, len(ss))
 
 	for _, pair := range ss {
-		kv := strings.SplitN(pair, kvSep, 2)
+		kv, err := splitEscaped(pair, kvSep, 2, sep, kvSep)
+		if err != nil {
+			return nil, err
+		}
+
 		if len(kv) != 2 {
 			return nil, fmt.Errorf("%s must be formatted as key%svalue", pair, kvSep)
 		}
 
-		v, err := convert(kv[1])
+		v, err := convert(unescapeField(kv[1], sep, kvSep))
 		if err != nil {
 			return nil, err
 		}
 
-		out[kv[0]] = v
+		out[unescapeField(kv[0], sep, kvSep)] = v
 	}
 
 	return out, nil
@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/pflag"
 )
@@ -25,7 +26,7 @@ func TestBuildMergedFlagReadError(t *testing.T) {
 		},
 	}
 
-	_, err := s.buildMerged()
+	_, _, err := s.buildMerged()
 	if err == nil {
 		t.Fatal("expected error from buildMerged")
 	}
@@ -59,3 +60,105 @@ func TestReadFlagValueUnsupportedMapElementType(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestExpandPath(t *testing.T) {
+	t.Setenv("HOME", "/home/tester")
+	t.Setenv("APP_DIR", "/srv/app")
+
+	tests := map[string]string{
+		"~":                   "/home/tester",
+		"~/app.toml":          "/home/tester/app.toml",
+		"$APP_DIR/app.toml":   "/srv/app/app.toml",
+		"${HOME}/.config/app": "/home/tester/.config/app",
+		"/etc/app/~":          "/etc/app/~",
+		"relative/app.toml":   "relative/app.toml",
+	}
+
+	for in, want := range tests {
+		if got := expandPath(in); got != want {
+			t.Errorf("expandPath(%q): expected %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestPollingSourceBackoff(t *testing.T) {
+	p := &PollingSource{Interval: 100 * time.Millisecond, MaxBackoff: time.Second, Jitter: 0.1}
+
+	tests := []struct {
+		failures int
+		base     time.Duration
+	}{
+		{failures: 0, base: 100 * time.Millisecond},
+		{failures: 1, base: 200 * time.Millisecond},
+		{failures: 3, base: 800 * time.Millisecond},
+		{failures: 10, base: time.Second},
+	}
+
+	for _, tt := range tests {
+		got := p.nextWait(tt.failures)
+		spread := tt.base / 10
+		if got < tt.base-spread || got > tt.base+spread {
+			t.Errorf("failures=%d: expected %v ± %v, got %v", tt.failures, tt.base, spread, got)
+		}
+	}
+}
+
+func TestEscapedSliceAndMapValues(t *testing.T) {
+	sliceTests := []struct {
+		in   string
+		want []string
+	}{
+		{in: "a,b", want: []string{"a", "b"}},
+		{in: `a\,b,c`, want: []string{"a,b", "c"}},
+		{in: `"a,b",c`, want: []string{"a,b", "c"}},
+		{in: `"say \"hi\"",x`, want: []string{`say "hi"`, "x"}},
+		{in: `C:\dir,d\\e`, want: []string{`C:\dir`, `d\e`}},
+		{in: `a"b,c`, want: []string{`a"b`, "c"}},
+	}
+
+	hook := stringToTypedSliceHookFunc(",").(func(reflect.Type, reflect.Type, any) (any, error))
+
+	for _, tt := range sliceTests {
+		got, err := hook(reflect.TypeFor[string](), reflect.TypeFor[[]string](), tt.in)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.in, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %q, got %q", tt.in, tt.want, got)
+		}
+	}
+
+	got, err := parseDefaultMap(`dsn="host=db user=app",url=http://x/?a=1\,b=2,"k=1"=v`, "=", ",",
+		func(s string) (string, error) { return s, nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{"dsn": "host=db user=app", "url": "http://x/?a=1,b=2", "k=1": "v"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if _, err = parseDefaultMap(`a="b,c=d`, "=", ",", func(s string) (string, error) { return s, nil }); err == nil {
+		t.Error("expected error for unterminated quote, got nil")
+	}
+}
+
+func TestAnnotateTOMLQuotedKeys(t *testing.T) {
+	s := &StructConfig{options: (&Options{}).fillDefaults()}
+
+	comments := map[string][]string{
+		"a.b":     {"env: APP_A_B"},
+		"db.host": {"env: APP_DB_HOST"},
+		"db.port": {"env: APP_DB_PORT"},
+	}
+
+	text := "\"a.b\" = 1\n'a'.\"b\" = 2\n\n[\"db\"]\nhost = 'h'\n\"port\" = 5432\n\n['db.host']\nx = 1\n"
+	want := "\"a.b\" = 1\n# env: APP_A_B\n'a'.\"b\" = 2\n\n[\"db\"]\n# env: APP_DB_HOST\nhost = 'h'\n# env: APP_DB_PORT\n\"port\" = 5432\n\n['db.host']\nx = 1\n"
+
+	if got := s.annotateTOML(text, comments); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
@@ -0,0 +1,126 @@
+package structconfig
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// boolTags are the tags besides ignored that take true or false.
+var boolTags = []string{
+	tagRequired, tagAllowEmpty, tagSplitWords, tagSecret, tagExpand,
+	tagEnvNoPrefix, tagGate, tagOptional, tagURLComponents,
+}
+
+// checkTags checks the tag values of every field of spec, nested structs
+// included, and reports all bad values together, so a misspelled
+// required:"yess" is not read as false. Specs that are not struct pointers
+// are left to gatherInfo.
+func (s *StructConfig) checkTags(spec any) error {
+	typ := reflect.TypeOf(spec)
+	if typ == nil || typ.Kind() != reflect.Pointer || typ.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	return errors.Join(s.checkStructTags(typ.Elem(), "")...)
+}
+
+func (s *StructConfig) checkStructTags(typ reflect.Type, path string) []error {
+	var errs []error
+
+	marker, err := s.markerTag(typ)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if path != "" {
+			name = path + "." + field.Name
+		}
+
+		field.Tag, err = s.expandCompositeTag(field)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("field %s: %w", name, err))
+			continue
+		}
+
+		field.Tag = withMarkerTag(field.Tag, marker)
+
+		// The other tags of an ignored field are never read.
+		if ignored, err := isTrue2(field.Tag.Get(tagIgnored)); ignored {
+			continue
+		} else if err != nil {
+			errs = append(errs, fmt.Errorf("field %s: bad %s tag value %q: want true or false", name, tagIgnored, field.Tag.Get(tagIgnored)))
+			continue
+		}
+
+		for _, err := range checkFieldTags(field) {
+			errs = append(errs, fmt.Errorf("field %s: %w", name, err))
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if ft.Kind() == reflect.Struct && !isScalarType(field.Type) && !isRawSection(field.Type) {
+			errs = append(errs, s.checkStructTags(ft, name)...)
+		}
+	}
+
+	return errs
+}
+
+// checkFieldTags returns the errors of the parsers for the tags of field.
+func checkFieldTags(field reflect.StructField) []error {
+	var errs []error
+
+	for _, name := range boolTags {
+		if tag, ok := field.Tag.Lookup(name); ok {
+			if _, err := isTrue2(tag); err != nil {
+				errs = append(errs, fmt.Errorf("bad %s tag value %q: want true or false", name, tag))
+			}
+		}
+	}
+
+	checks := []struct {
+		tag   string
+		check func(tag string) error
+	}{
+		{tagMustExist, func(tag string) error {
+			_, err := parsePathCheck(field, tag)
+			return err
+		}},
+		{tagNormalize, func(tag string) error {
+			_, err := parseNormalize(field, tag)
+			return err
+		}},
+		{tagDurationUnit, func(tag string) error {
+			_, err := parseDurationUnit(field, tag)
+			return err
+		}},
+		{tagReload, func(tag string) error {
+			_, err := parseReloadTag(field, tag)
+			return err
+		}},
+		{tagFeature, func(tag string) error {
+			return checkFeatureTag(field, tag)
+		}},
+	}
+
+	for _, c := range checks {
+		if tag, ok := field.Tag.Lookup(c.tag); ok {
+			if err := c.check(tag); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errs
+}
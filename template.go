@@ -0,0 +1,62 @@
+package structconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// parseTemplate parses a template tag. The tag is accepted on string and
+// *string fields and cannot be combined with a default.
+func parseTemplate(field reflect.StructField, info varInfo, tag string) (*template.Template, error) {
+	if indirectKind(field.Type) != reflect.String {
+		return nil, fmt.Errorf("%s tag on field %s requires a string field, got %s", tagTemplate, field.Name, field.Type)
+	}
+
+	if _, ok := info.defaultRaw(); ok {
+		return nil, fmt.Errorf("%s tag on field %s cannot be combined with a default", tagTemplate, field.Name)
+	}
+
+	tmpl, err := template.New(field.Name).Option("missingkey=error").Parse(tag)
+	if err != nil {
+		return nil, fmt.Errorf("bad %s tag value for field %s: %w", tagTemplate, field.Name, err)
+	}
+
+	return tmpl, nil
+}
+
+// renderTemplates sets the fields with a template tag that no source set by
+// executing the template against the decoded spec. Fields are rendered in
+// declaration order, so a template can use an earlier rendered field.
+func (s *StructConfig) renderTemplates(spec reflect.Value) error {
+	for _, info := range s.infos {
+		if info.template == nil {
+			continue
+		}
+
+		v := fieldByIndex(spec, info.index)
+		if v.Kind() == reflect.Pointer {
+			if !v.IsNil() {
+				continue
+			}
+
+			v.Set(reflect.New(v.Type().Elem()))
+			v = v.Elem()
+		}
+
+		if v.Len() > 0 {
+			continue
+		}
+
+		var b strings.Builder
+		if err := info.template.Execute(&b, spec.Interface()); err != nil {
+			return fmt.Errorf("render template for field %s: %w", info.Name, err)
+		}
+
+		v.SetString(b.String())
+		s.audit(AuditHook, info.Key, "rendered template")
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package structconfig
+
+import (
+	"encoding"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	locationType        = reflect.TypeFor[time.Location]()
+	mailAddressType     = reflect.TypeFor[mail.Address]()
+)
+
+// scalarParsers parse struct types that are configured as a single text value
+// but do not implement encoding.TextUnmarshaler. Each returns a pointer to the
+// parsed value.
+var scalarParsers = map[reflect.Type]func(string) (any, error){
+	locationType:    parseLocation,
+	mailAddressType: parseMailAddress,
+}
+
+// isScalarType reports whether a struct-kinded typ is decoded from a single
+// text value rather than treated as a nested struct. Types registered with
+// RegisterFlagValue are scalars of any kind.
+func isScalarType(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if _, ok := scalarParsers[typ]; ok {
+		return true
+	}
+
+	if _, ok := lookupFlagValue(typ); ok {
+		return true
+	}
+
+	return isTextType(typ)
+}
+
+// isTextType reports whether values of typ, or pointers to them, can be parsed
+// with encoding.TextUnmarshaler. Such fields (big.Int, big.Float, decimal
+// types, and so on) are treated as scalars rather than nested structs.
+func isTextType(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	return reflect.PointerTo(typ).Implements(textUnmarshalerType)
+}
+
+// parseScalar parses text into a new value of the scalar type typ and returns
+// a pointer to it.
+func parseScalar(typ reflect.Type, text string) (any, error) {
+	if newValue, ok := lookupFlagValue(typ); ok {
+		return parseFlagValue(typ, newValue, text)
+	}
+
+	if parse, ok := scalarParsers[typ]; ok {
+		return parse(text)
+	}
+
+	result := reflect.New(typ)
+	if err := result.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+		return nil, err
+	}
+
+	return result.Interface(), nil
+}
+
+func parseLocation(name string) (any, error) {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", name, err)
+	}
+
+	// The decoder copies the Location into the field. time.Local is
+	// initialized lazily, so force that before it is copied.
+	_ = loc.String()
+
+	return loc, nil
+}
+
+func parseMailAddress(addr string) (any, error) {
+	a, err := mail.ParseAddress(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email address %q: %w", addr, err)
+	}
+
+	return a, nil
+}
+
+// scalarHookFunc decodes strings and numbers into scalar struct types and
+// types implementing encoding.TextUnmarshaler. Numbers are formatted back to
+// text first, so values that must keep full precision should be quoted in
+// config files.
+func scalarHookFunc() func(reflect.Type, reflect.Type, any) (any, error) {
+	return func(f, t reflect.Type, data any) (any, error) {
+		if t.Kind() == reflect.Pointer || !isScalarType(t) {
+			return data, nil
+		}
+
+		var text string
+
+		switch v := reflect.ValueOf(data); f.Kind() {
+		case reflect.String:
+			text = v.String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			text = strconv.FormatInt(v.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			text = strconv.FormatUint(v.Uint(), 10)
+		case reflect.Float32, reflect.Float64:
+			text = strconv.FormatFloat(v.Float(), 'f', -1, f.Bits())
+		default:
+			return data, nil
+		}
+
+		return parseScalar(t, text)
+	}
+}
+
+// textValue is the pflag.Value registered for scalar fields. It validates the
+// text on Set, so bad values are reported as flag errors, and keeps the raw
+// text for the decode hooks. Type reports the Go type, e.g. "big.Int".
+type textValue struct {
+	typ   reflect.Type
+	value string
+}
+
+func (v *textValue) String() string { return v.value }
+
+func (v *textValue) Set(s string) error {
+	if _, err := parseScalar(v.typ, s); err != nil {
+		return err
+	}
+
+	v.value = s
+
+	return nil
+}
+
+func (v *textValue) Type() string { return v.typ.String() }
@@ -0,0 +1,152 @@
+package structconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSConfig holds the TLS settings most services need. Use it as a named
+// field so its keys, env vars, and flags get the field's prefix:
+//
+//	type Config struct {
+//		TLS structconfig.TLSConfig
+//	}
+//
+// The files are checked to be readable while processing, and the version and
+// client auth policy are validated when parsed.
+type TLSConfig struct {
+	CertFile   string        `split_words:"true" must_exist:"file,readable" desc:"certificate file (PEM)"`
+	KeyFile    string        `split_words:"true" must_exist:"file,readable" desc:"private key file (PEM)"`
+	CAFile     string        `split_words:"true" must_exist:"file,readable" desc:"CA bundle for verifying peer certificates (PEM)"`
+	MinVersion TLSVersion    `split_words:"true" default:"1.2" desc:"minimum TLS version: 1.0, 1.1, 1.2, or 1.3"`
+	ClientAuth TLSClientAuth `split_words:"true" default:"none" desc:"client certificate policy: none, request, require, verify-if-given, or require-and-verify"`
+}
+
+// Build returns a *tls.Config for the settings. The certificate pair is
+// loaded when set, and the CA bundle verifies both servers (RootCAs) and
+// clients (ClientCAs).
+func (c TLSConfig) Build() (*tls.Config, error) {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return nil, errors.New("tls: cert file and key file must be set together")
+	}
+
+	cfg := &tls.Config{
+		MinVersion: uint16(c.MinVersion),
+		ClientAuth: tls.ClientAuthType(c.ClientAuth),
+	}
+
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: %w", err)
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.CAFile != "" {
+		data, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("tls: no certificates found in %s", c.CAFile)
+		}
+
+		cfg.RootCAs = pool
+		cfg.ClientCAs = pool
+	} else if cfg.ClientAuth >= tls.VerifyClientCertIfGiven {
+		return nil, fmt.Errorf("tls: client auth %s requires a CA file", c.ClientAuth)
+	}
+
+	return cfg, nil
+}
+
+// TLSVersion is a TLS protocol version configured as "1.0" to "1.3".
+type TLSVersion uint16
+
+var tlsVersions = map[string]TLSVersion{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// String returns the version as configured, e.g. "1.2", or "" when unset.
+func (v TLSVersion) String() string {
+	for name, version := range tlsVersions {
+		if version == v {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v TLSVersion) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+// An empty text leaves the version unset, which lets crypto/tls pick its
+// default.
+func (v *TLSVersion) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*v = 0
+		return nil
+	}
+
+	version, ok := tlsVersions[string(text)]
+	if !ok {
+		return fmt.Errorf("invalid TLS version %q: want 1.0, 1.1, 1.2, or 1.3", text)
+	}
+
+	*v = version
+
+	return nil
+}
+
+// TLSClientAuth is a tls.ClientAuthType configured by name.
+type TLSClientAuth tls.ClientAuthType
+
+var tlsClientAuths = map[string]TLSClientAuth{
+	"none":               TLSClientAuth(tls.NoClientCert),
+	"request":            TLSClientAuth(tls.RequestClientCert),
+	"require":            TLSClientAuth(tls.RequireAnyClientCert),
+	"verify-if-given":    TLSClientAuth(tls.VerifyClientCertIfGiven),
+	"require-and-verify": TLSClientAuth(tls.RequireAndVerifyClientCert),
+}
+
+// String returns the policy name, e.g. "require-and-verify".
+func (a TLSClientAuth) String() string {
+	for name, auth := range tlsClientAuths {
+		if auth == a {
+			return name
+		}
+	}
+
+	return tls.ClientAuthType(a).String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (a TLSClientAuth) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *TLSClientAuth) UnmarshalText(text []byte) error {
+	auth, ok := tlsClientAuths[string(text)]
+	if !ok {
+		return fmt.Errorf("invalid TLS client auth %q: want none, request, require, verify-if-given, or require-and-verify", text)
+	}
+
+	*a = auth
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package structconfig
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// checkURLComponentsTag checks that a url_components tag is on a struct
+// field.
+func checkURLComponentsTag(field reflect.StructField, tag string) (bool, error) {
+	enabled, err := isTrue2(tag)
+	if err != nil {
+		return false, fmt.Errorf("bad %s tag value for field %s: %w", tagURLComponents, field.Name, err)
+	}
+
+	if enabled && (indirectKind(field.Type) != reflect.Struct || isScalarType(field.Type)) {
+		return false, fmt.Errorf("%s tag on field %s requires a struct field, got %s", tagURLComponents, field.Name, field.Type)
+	}
+
+	return enabled, nil
+}
+
+// urlComponents splits a URL such as postgres://user:pw@host:5432/app into
+// values keyed by the field names they fill: scheme, host, port, user or
+// username, password, and name, database, db, or dbname for the path. Query
+// parameters such as sslmode are added under their own names. URLs without
+// a host, such as sqlite:///data.db, fill the path fields only.
+func urlComponents(raw string) (map[string]string, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Opaque != "" {
+		// The parse error holds the URL, which may hold a password.
+		return nil, fmt.Errorf("not a URL")
+	}
+
+	comps := make(map[string]string)
+
+	for key, values := range u.Query() {
+		if len(values) > 0 {
+			comps[strings.ToLower(key)] = values[0]
+		}
+	}
+
+	set := func(val string, keys ...string) {
+		if val == "" {
+			return
+		}
+
+		for _, key := range keys {
+			comps[key] = val
+		}
+	}
+
+	set(u.Scheme, "scheme")
+	set(u.Hostname(), "host")
+	set(u.Port(), "port")
+	set(u.User.Username(), "user", "username")
+
+	if pw, ok := u.User.Password(); ok {
+		set(pw, "password")
+	}
+
+	set(strings.TrimPrefix(u.Path, "/"), "name", "database", "db", "dbname")
+
+	return comps, nil
+}
+
+// urlComponent returns the value for a field of a struct tagged
+// url_components from the URL in the struct's env var, and that env var.
+func (s *StructConfig) urlComponent(info varInfo) (env, val string, ok bool, err error) {
+	if info.urlSource == nil {
+		return "", "", false, nil
+	}
+
+	env, raw, ok := lookupEnv(*info.urlSource)
+	if !ok {
+		return "", "", false, nil
+	}
+
+	comps, err := urlComponents(raw)
+	if err != nil {
+		return "", "", false, fmt.Errorf("env var %s: %w", env, err)
+	}
+
+	name := strings.ToLower(strings.TrimPrefix(info.Key, info.urlSource.Key+s.options.KeyDelimiter))
+	val, ok = comps[name]
+
+	return env, val, ok, nil
+}
@@ -0,0 +1,97 @@
+package structconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+const (
+	versionText = "text"
+	versionJSON = "json"
+)
+
+// VersionInfo is the structured version printed by the built-in version
+// flag when set as Options.Version. Empty fields are left out; GoVersion
+// defaults to the runtime version.
+type VersionInfo struct {
+	Version   string `json:"version,omitempty"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"go_version,omitempty"`
+}
+
+// VersionProvider returns the version info printed by the built-in version
+// flag. VersionInfo implements it for versions known at build time.
+type VersionProvider interface {
+	VersionInfo() VersionInfo
+}
+
+// VersionInfo returns v, so a VersionInfo can be used as a VersionProvider.
+func (v VersionInfo) VersionInfo() VersionInfo {
+	return v
+}
+
+// String formats v as "name: value" lines, as printed by --version.
+func (v VersionInfo) String() string {
+	var b strings.Builder
+
+	for _, line := range [][2]string{
+		{"version", v.Version},
+		{"commit", v.Commit},
+		{"date", v.Date},
+		{"go version", v.GoVersion},
+	} {
+		if line[1] != "" {
+			fmt.Fprintf(&b, "%s: %s\n", line[0], line[1])
+		}
+	}
+
+	return b.String()
+}
+
+// versionInfo returns the version info from Options.Version, or one holding
+// the VersionFunc output when only that is set.
+func (s *StructConfig) versionInfo() VersionInfo {
+	var info VersionInfo
+
+	if s.options.Version != nil {
+		info = s.options.Version.VersionInfo()
+	} else {
+		info.Version = strings.TrimSuffix(s.options.VersionFunc(), "\n")
+	}
+
+	if info.GoVersion == "" {
+		info.GoVersion = runtime.Version()
+	}
+
+	return info
+}
+
+// formatVersion renders the version for the version flag value format. Text
+// without Options.Version is the VersionFunc output as is.
+func (s *StructConfig) formatVersion(format string) (string, error) {
+	switch format {
+	case versionText:
+		if s.options.Version == nil {
+			v := s.options.VersionFunc()
+			if !strings.HasSuffix(v, "\n") {
+				v += "\n"
+			}
+
+			return v, nil
+		}
+
+		return s.versionInfo().String(), nil
+	case versionJSON:
+		data, err := json.MarshalIndent(s.versionInfo(), "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("encode version: %w", err)
+		}
+
+		return string(data) + "\n", nil
+	default:
+		return "", fmt.Errorf("bad --%s value %q: want %s or %s", s.options.FlagNames.Version, format, versionText, versionJSON)
+	}
+}
@@ -0,0 +1,164 @@
+package structconfig
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// WarningKind classifies a non-fatal issue reported through Options.OnWarning.
+type WarningKind string
+
+// Warning kinds reported through Options.OnWarning.
+const (
+	WarningDeprecated         WarningKind = "deprecated"
+	WarningUnknownKey         WarningKind = "unknown-key"
+	WarningUnknownEnv         WarningKind = "unknown-env"
+	WarningIgnoredFileError   WarningKind = "ignored-file-error"
+	WarningInsecureSecretFile WarningKind = "insecure-secret-file"
+	WarningFeatureError       WarningKind = "feature-error"
+)
+
+// Warning describes a non-fatal issue found while processing configuration.
+type Warning struct {
+	Kind WarningKind
+	// Key is the config key, flag, or file path the warning refers to.
+	Key     string
+	Message string
+}
+
+// String formats the warning for logging.
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s: %s", w.Kind, w.Key, w.Message)
+}
+
+type warnFuncKey struct{}
+
+// warn reports w through Options.OnWarning, if set, once s.mu is released.
+func (s *StructConfig) warn(w Warning) {
+	if onWarning := s.options.OnWarning; onWarning != nil {
+		s.queue(func() { onWarning(w) })
+	}
+}
+
+// providerContext returns the context passed to providers, carrying the
+// warning callback so in-tree providers can report issues.
+func (s *StructConfig) providerContext() context.Context {
+	return context.WithValue(s.options.Context, warnFuncKey{}, s.warn)
+}
+
+// warnFromContext reports w through the callback stored by providerContext.
+func warnFromContext(ctx context.Context, w Warning) {
+	if warn, ok := ctx.Value(warnFuncKey{}).(func(Warning)); ok {
+		warn(w)
+	}
+}
+
+// checkSecretFileMode warns when a secret file is readable by other users.
+func checkSecretFileMode(ctx context.Context, path string, fi fs.FileInfo) {
+	if fi.Mode().Perm()&0o004 != 0 {
+		warnFromContext(ctx, Warning{
+			Kind:    WarningInsecureSecretFile,
+			Key:     path,
+			Message: fmt.Sprintf("secret file is world-readable (mode %s)", fi.Mode().Perm()),
+		})
+	}
+}
+
+// warnUnknownFileKeys reports config file keys that do not bind to any field.
+func (s *StructConfig) warnUnknownFileKeys() {
+	if s.options.OnWarning == nil {
+		return
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(s.flattenMap("", s.fileData))) {
+		if !s.isKnownKey(key) {
+			s.warn(Warning{
+				Kind:    WarningUnknownKey,
+				Key:     key,
+				Message: fmt.Sprintf("key in %s does not match any field", s.configFile),
+			})
+		}
+	}
+}
+
+// checkUnknownEnv reports environment variables that start with the env
+// prefix but do not bind to any field or built-in setting, such as a
+// misspelled MYAPP_PRT. They are returned as an error with
+// Options.StrictEnv and reported as warnings otherwise.
+func (s *StructConfig) checkUnknownEnv() error {
+	if s.prefix == "" || (s.options.OnWarning == nil && !s.options.StrictEnv) {
+		return nil
+	}
+
+	envPrefix := strings.ToUpper(s.prefix) + "_"
+
+	known := map[string]bool{
+		envPrefix + envConfigPathSuffix: true,
+		envPrefix + envConfigTypeSuffix: true,
+		envPrefix + envProfileSuffix:    true,
+	}
+
+	for _, info := range s.infos {
+		for _, name := range info.boundEnvNames() {
+			known[name] = true
+		}
+	}
+
+	var unknown []string
+
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, envPrefix) && !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+
+	slices.Sort(unknown)
+
+	if s.options.StrictEnv && len(unknown) > 0 {
+		return fmt.Errorf("env vars do not match any field: %s", strings.Join(unknown, ", "))
+	}
+
+	for _, name := range unknown {
+		s.warn(Warning{
+			Kind:    WarningUnknownEnv,
+			Key:     name,
+			Message: fmt.Sprintf("env var has prefix %s but does not match any field", envPrefix),
+		})
+	}
+
+	return nil
+}
+
+// isKnownKey reports whether key is a field key or nested below one, as the
+// entries of a map field are.
+func (s *StructConfig) isKnownKey(key string) bool {
+	for _, info := range s.infos {
+		if key == info.Key || strings.HasPrefix(key, info.Key+s.options.KeyDelimiter) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// warnDeprecatedFlags reports field flags tagged flag_deprecated that were
+// set on the command line.
+func (s *StructConfig) warnDeprecatedFlags() {
+	for _, info := range s.infos {
+		msg := info.tag.Get(tagFlagDeprecated)
+		if msg == "" || info.Flag == "" || info.Flag == skipTagValue {
+			continue
+		}
+
+		_, name, ok, _ := s.changedFlag(info)
+		if ok {
+			s.warn(Warning{Kind: WarningDeprecated, Key: "--" + name, Message: msg})
+		}
+	}
+}